@@ -1,12 +1,14 @@
 package anthropic
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	utils "github.com/adamchol/go-anthropic-sdk/internal"
 )
@@ -16,14 +18,23 @@ type Client struct {
 	config ClientConfig
 
 	requestBuilder utils.RequestBuilder
+	rateLimiter    *rateLimiter
+	modelCache     *modelCache
 }
 
 // NewClientWithConfig creates an Anthropic API client with specified configuration
 func NewClientWithConfig(config ClientConfig) *Client {
-	return &Client{
+	client := &Client{
 		config:         config,
 		requestBuilder: utils.NewRequestBuilder(),
 	}
+	if config.RateLimiter != nil {
+		client.rateLimiter = newRateLimiter(*config.RateLimiter)
+	}
+	if config.ModelCacheTTL > 0 {
+		client.modelCache = newModelCache(config.ModelCacheTTL)
+	}
+	return client
 }
 
 // NewClient creates an Anthropic API client with API key
@@ -31,6 +42,11 @@ func NewClient(apiKey string) *Client {
 	return NewClientWithConfig(DefaultConfig(apiKey))
 }
 
+// response is implemented by response types which expose information from HTTP headers
+type response interface {
+	setHeader(header http.Header)
+}
+
 type requestOptions struct {
 	body   any
 	header http.Header
@@ -61,7 +77,7 @@ func (c *Client) newRequest(ctx context.Context, method, url string, setters ...
 }
 
 func (c *Client) sendRequest(request *http.Request, v any) error {
-	resp, err := c.config.HTTPClient.Do(request)
+	resp, err := c.doRequest(request)
 	if err != nil {
 		return err
 	}
@@ -69,11 +85,17 @@ func (c *Client) sendRequest(request *http.Request, v any) error {
 	defer resp.Body.Close()
 
 	if isFailureStatusCode(resp) {
-		// TODO: handle errors properly
 		return handleErrorResponse(resp)
 	}
 
-	return json.NewDecoder(resp.Body).Decode(v)
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return err
+	}
+
+	if r, ok := v.(response); ok {
+		r.setHeader(resp.Header)
+	}
+	return nil
 }
 
 func (c *Client) sendStreamRequest(req *http.Request) (*streamReader, error) {
@@ -82,15 +104,28 @@ func (c *Client) sendStreamRequest(req *http.Request) (*streamReader, error) {
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := c.config.HTTPClient.Do(req)
+	resp, err := c.doRequest(req)
 	if err != nil {
 		return new(streamReader), err
 	}
 
-	return &streamReader{
-		response: resp,
-		reader:   bufio.NewReader(resp.Body),
-	}, nil
+	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
+		return new(streamReader), handleErrorResponse(resp)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return new(streamReader), &RequestError{
+			HTTPStatusCode: resp.StatusCode,
+			RequestID:      resp.Header.Get(requestIDHeader),
+			Body:           body,
+			Err:            fmt.Errorf("%w: \"%s\"", ErrUnexpectedContentType, resp.Header.Get("Content-Type")),
+		}
+	}
+
+	return newStreamReader(req.Context(), resp, c.rateLimiter), nil
 }
 
 func (c *Client) setCommonHeaders(req *http.Request) {
@@ -103,27 +138,25 @@ func (c *Client) fullURL(suffix string) string {
 	return fmt.Sprintf("%s%s", c.config.BaseUrl, suffix)
 }
 
-func isFailureStatusCode(response *http.Response) bool {
-	return response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest
-}
-
-type ErrorResponse struct {
-	Type  string    `json:"type"`
-	Error *APIError `json:"error"`
-}
-
-type APIError struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
-}
-
-func handleErrorResponse(resp *http.Response) error {
-	var errResp ErrorResponse
+// listURL returns the URL of a list endpoint with optional pagination parameters
+func (c *Client) listURL(suffix, beforeID, afterID string, limit int) string {
+	query := url.Values{}
+	if beforeID != "" {
+		query.Set("before_id", beforeID)
+	}
+	if afterID != "" {
+		query.Set("after_id", afterID)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
 
-	err := json.NewDecoder(resp.Body).Decode(&errResp)
-	if err != nil || errResp.Error == nil {
-		return err
+	if len(query) == 0 {
+		return c.fullURL(suffix)
 	}
+	return c.fullURL(suffix) + "?" + query.Encode()
+}
 
-	return errors.New(errResp.Error.Message)
+func isFailureStatusCode(response *http.Response) bool {
+	return response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest
 }
@@ -1,6 +1,9 @@
 package anthropic
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 const (
 	anthropicAPIURLv1 = "https://api.anthropic.com/v1"
@@ -23,6 +26,20 @@ type ClientConfig struct {
 	APIVersion APIVersion
 
 	HTTPClient *http.Client
+
+	// RetryPolicy configures retries of failed requests. See [RetryPolicy] for details
+	RetryPolicy RetryPolicy
+
+	// RateLimiter enables the client-side rate limiter shared by all requests of the client. It is disabled when nil
+	RateLimiter *RateLimiterConfig
+
+	// ModelCacheTTL enables validation of the model of message requests. Each model is looked up with
+	// [Client.GetModel] and the result is cached for this duration. Unknown models make requests fail
+	// with [ErrModelNotAvailable] before being sent. Validation is disabled when zero
+	ModelCacheTTL time.Duration
+
+	// DisableRequestValidation turns off [MessageRequest.Validate] before sending message requests
+	DisableRequestValidation bool
 }
 
 // DefaultConfig creates a standard configuration with api key.
@@ -33,5 +50,7 @@ func DefaultConfig(apiKey string) ClientConfig {
 		BaseUrl:    anthropicAPIURLv1,
 		APIVersion: latest,
 		HTTPClient: &http.Client{},
+
+		RetryPolicy: DefaultRetryPolicy(),
 	}
 }
@@ -0,0 +1,157 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error types returned by Anthropic API in the "error.type" field
+const (
+	InvalidRequestErrorType = "invalid_request_error"
+	AuthenticationErrorType = "authentication_error"
+	PermissionErrorType     = "permission_error"
+	NotFoundErrorType       = "not_found_error"
+	RequestTooLargeType     = "request_too_large"
+	RateLimitErrorType      = "rate_limit_error"
+	APIErrorType            = "api_error"
+	OverloadedErrorType     = "overloaded_error"
+)
+
+// StatusOverloaded is a non-standard HTTP status code used by Anthropic API when it is temporarily overloaded
+const StatusOverloaded = 529
+
+const requestIDHeader = "request-id"
+
+type ErrorResponse struct {
+	Type  string    `json:"type"`
+	Error *APIError `json:"error"`
+}
+
+// APIError is returned when Anthropic API responds with an error object.
+// Use [errors.As] to access its fields.
+type APIError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+
+	// HTTPStatusCode is a status code of the response. It is zero for errors received in the middle of a stream
+	HTTPStatusCode int `json:"-"`
+	// RequestID is a value of the "request-id" response header
+	RequestID string `json:"-"`
+	// Body is a raw body of the error response
+	Body []byte `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.HTTPStatusCode > 0 {
+		return fmt.Sprintf("API error of type \"%s\" (status code %d): %s", e.Type, e.HTTPStatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("API error of type \"%s\": %s", e.Type, e.Message)
+}
+
+// RequestError is returned when the request failed with a status code, but the response body could not be decoded as an API error
+type RequestError struct {
+	HTTPStatusCode int
+	RequestID      string
+	Body           []byte
+	Err            error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request error (status code %d): %s", e.HTTPStatusCode, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func handleErrorResponse(resp *http.Response) error {
+	requestID := resp.Header.Get(requestIDHeader)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &RequestError{
+			HTTPStatusCode: resp.StatusCode,
+			RequestID:      requestID,
+			Err:            err,
+		}
+	}
+
+	var errResp ErrorResponse
+	err = json.Unmarshal(body, &errResp)
+	if err != nil || errResp.Error == nil {
+		if err == nil {
+			err = errors.New(http.StatusText(resp.StatusCode))
+		}
+		return &RequestError{
+			HTTPStatusCode: resp.StatusCode,
+			RequestID:      requestID,
+			Body:           body,
+			Err:            err,
+		}
+	}
+
+	errResp.Error.HTTPStatusCode = resp.StatusCode
+	errResp.Error.RequestID = requestID
+	errResp.Error.Body = body
+	return errResp.Error
+}
+
+// errorDetails extracts the status code and API error type from err, if it is an [*APIError] or a [*RequestError]
+func errorDetails(err error) (statusCode int, errType string) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode, apiErr.Type
+	}
+
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode, ""
+	}
+
+	return 0, ""
+}
+
+func isErrorOf(err error, errType string, statusCode int) bool {
+	code, t := errorDetails(err)
+	return t == errType || (code != 0 && code == statusCode)
+}
+
+// IsInvalidRequest reports whether err is an "invalid_request_error" or a 400 response
+func IsInvalidRequest(err error) bool {
+	return isErrorOf(err, InvalidRequestErrorType, http.StatusBadRequest)
+}
+
+// IsAuthentication reports whether err is an "authentication_error" or a 401 response
+func IsAuthentication(err error) bool {
+	return isErrorOf(err, AuthenticationErrorType, http.StatusUnauthorized)
+}
+
+// IsPermission reports whether err is a "permission_error" or a 403 response
+func IsPermission(err error) bool {
+	return isErrorOf(err, PermissionErrorType, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is a "not_found_error" or a 404 response
+func IsNotFound(err error) bool {
+	return isErrorOf(err, NotFoundErrorType, http.StatusNotFound)
+}
+
+// IsRateLimit reports whether err is a "rate_limit_error" or a 429 response
+func IsRateLimit(err error) bool {
+	return isErrorOf(err, RateLimitErrorType, http.StatusTooManyRequests)
+}
+
+// IsOverloaded reports whether err is an "overloaded_error" or a 529 response
+func IsOverloaded(err error) bool {
+	return isErrorOf(err, OverloadedErrorType, StatusOverloaded)
+}
+
+// IsServerError reports whether err is an "api_error" or any 5xx response
+func IsServerError(err error) bool {
+	code, t := errorDetails(err)
+	return t == APIErrorType || code >= http.StatusInternalServerError
+}
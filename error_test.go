@@ -0,0 +1,80 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockErrorClient(statusCode int, body string) *Client {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("request-id", "req_123")
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header:     header,
+			}
+		},
+	}
+
+	return NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+}
+
+func TestHandleAPIErrorResponse(t *testing.T) {
+	const body = `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	client := mockErrorClient(StatusOverloaded, body)
+
+	request, err := client.newRequest(context.Background(), http.MethodPost, "")
+	assert.NoError(t, err)
+
+	err = client.sendRequest(request, nil)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, OverloadedErrorType, apiErr.Type)
+	assert.Equal(t, "Overloaded", apiErr.Message)
+	assert.Equal(t, StatusOverloaded, apiErr.HTTPStatusCode)
+	assert.Equal(t, "req_123", apiErr.RequestID)
+	assert.Equal(t, body, string(apiErr.Body))
+
+	assert.True(t, IsOverloaded(err))
+	assert.True(t, IsServerError(err))
+	assert.False(t, IsRateLimit(err))
+	assert.False(t, IsInvalidRequest(err))
+}
+
+func TestHandleNonJSONErrorResponse(t *testing.T) {
+	client := mockErrorClient(http.StatusTooManyRequests, "<html>Too Many Requests</html>")
+
+	request, err := client.newRequest(context.Background(), http.MethodPost, "")
+	assert.NoError(t, err)
+
+	err = client.sendRequest(request, nil)
+
+	var reqErr *RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.Equal(t, http.StatusTooManyRequests, reqErr.HTTPStatusCode)
+	assert.Equal(t, "req_123", reqErr.RequestID)
+	assert.Equal(t, "<html>Too Many Requests</html>", string(reqErr.Body))
+
+	assert.True(t, IsRateLimit(err))
+	assert.False(t, IsOverloaded(err))
+}
+
+func TestErrorHelpersWithWrappedError(t *testing.T) {
+	err := &APIError{Type: InvalidRequestErrorType, Message: "bad", HTTPStatusCode: http.StatusBadRequest}
+	wrapped := errors.Join(errors.New("context"), err)
+
+	assert.True(t, IsInvalidRequest(wrapped))
+	assert.False(t, IsAuthentication(wrapped))
+	assert.False(t, IsRateLimit(errors.New("plain error")))
+}
@@ -0,0 +1,170 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// SSEEvent is a single event dispatched by [SSEDecoder]
+type SSEEvent struct {
+	// Event is a value of the "event" field. It is empty if the field was not set
+	Event string
+	Data  []byte
+	// ID is the last event ID set by the "id" field of this or any previous event
+	ID string
+	// Retry is a reconnection time in milliseconds set by the "retry" field. It is zero if the field was not set
+	Retry int
+	// Unterminated is set for an event dispatched at the end of the stream without a blank line after it.
+	// Such an event may have been cut off in the middle
+	Unterminated bool
+}
+
+// SSEDecoder decodes Server-Sent Events following the WHATWG event stream interpretation rules:
+// lines may end with CRLF, LF or CR, lines starting with a colon are comments, multiple "data" fields
+// are joined with LF, and events without data are not dispatched.
+//
+// Unlike in browsers, an event which is not followed by a blank line before the end of the stream is still dispatched.
+type SSEDecoder struct {
+	reader *bufio.Reader
+
+	lastEventID string
+	started     bool
+
+	// rest is the part of the last read segment which has not been returned by readLine yet
+	rest []byte
+	buf  []byte
+}
+
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	return &SSEDecoder{
+		reader: bufio.NewReader(r),
+	}
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Next returns the next event from the stream. It returns [io.EOF] when the stream has ended
+// and all events have been dispatched.
+func (d *SSEDecoder) Next() (SSEEvent, error) {
+	var (
+		eventType string
+		data      bytes.Buffer
+		retry     int
+	)
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			if err == io.EOF && data.Len() > 0 {
+				event := d.dispatch(eventType, &data, retry)
+				event.Unterminated = true
+				return event, nil
+			}
+			return SSEEvent{}, err
+		}
+
+		if len(line) == 0 {
+			if data.Len() == 0 {
+				eventType = ""
+				retry = 0
+				continue
+			}
+			return d.dispatch(eventType, &data, retry), nil
+		}
+
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, found := bytes.Cut(line, []byte(":"))
+		if found {
+			value = bytes.TrimPrefix(value, []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				d.lastEventID = string(value)
+			}
+		case "retry":
+			if isDigits(value) {
+				retry, _ = strconv.Atoi(string(value))
+			}
+		}
+	}
+}
+
+func (d *SSEDecoder) dispatch(eventType string, data *bytes.Buffer, retry int) SSEEvent {
+	return SSEEvent{
+		Event: eventType,
+		Data:  bytes.TrimSuffix(data.Bytes(), []byte("\n")),
+		ID:    d.lastEventID,
+		Retry: retry,
+	}
+}
+
+// readLine returns the next line without its terminator. A last line that is not terminated is also returned
+func (d *SSEDecoder) readLine() ([]byte, error) {
+	if !d.started {
+		d.started = true
+		if prefix, _ := d.reader.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+			d.reader.Discard(len(utf8BOM)) //nolint:errcheck
+		}
+	}
+
+	if len(d.rest) == 0 {
+		segment, err := d.readSegment()
+		if len(segment) == 0 || (err != nil && err != io.EOF) {
+			return nil, err
+		}
+		d.rest = segment
+	}
+
+	// A segment ends with LF, but CR also ends lines, so it may contain more of them
+	line := d.rest
+	i := bytes.IndexAny(line, "\r\n")
+	if i == -1 {
+		d.rest = nil
+		return line, nil
+	}
+
+	d.rest = line[i+1:]
+	// LF following CR is a part of the same CRLF line ending
+	if line[i] == '\r' && len(d.rest) > 0 && d.rest[0] == '\n' {
+		d.rest = d.rest[1:]
+	}
+	return line[:i], nil
+}
+
+// readSegment reads up to and including the next LF, or until the end of the stream if there is no LF.
+// The returned slice is only valid until the next call
+func (d *SSEDecoder) readSegment() ([]byte, error) {
+	segment, err := d.reader.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return segment, err
+	}
+
+	// The line is longer than the buffer of the reader
+	d.buf = append(d.buf[:0], segment...)
+	for err == bufio.ErrBufferFull {
+		segment, err = d.reader.ReadSlice('\n')
+		d.buf = append(d.buf, segment...)
+	}
+	return d.buf, err
+}
+
+func isDigits(bs []byte) bool {
+	for _, b := range bs {
+		if b < '0' || b > '9' {
+			return false
+		}
+	}
+	return len(bs) > 0
+}
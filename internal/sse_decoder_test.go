@@ -0,0 +1,95 @@
+package anthropic //nolint:testpackage // testing private field
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeAll(t *testing.T, input string) []SSEEvent {
+	t.Helper()
+
+	decoder := NewSSEDecoder(strings.NewReader(input))
+	var events []SSEEvent
+	for {
+		event, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Next() unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestSSEDecoderLineEndings(t *testing.T) {
+	want := []SSEEvent{
+		{Event: "first", Data: []byte("1")},
+		{Event: "second", Data: []byte("2")},
+		{Event: "third", Data: []byte("3")},
+	}
+
+	for name, input := range map[string]string{
+		"LF":    "event: first\ndata: 1\n\nevent: second\ndata: 2\n\nevent: third\ndata: 3\n\n",
+		"CRLF":  "event: first\r\ndata: 1\r\n\r\nevent: second\r\ndata: 2\r\n\r\nevent: third\r\ndata: 3\r\n\r\n",
+		"CR":    "event: first\rdata: 1\r\revent: second\rdata: 2\r\revent: third\rdata: 3\r\r",
+		"mixed": "event: first\r\ndata: 1\r\revent: second\ndata: 2\n\r\nevent: third\rdata: 3\r\n\n",
+	} {
+		got := decodeAll(t, input)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Next() got = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestSSEDecoderFields(t *testing.T) {
+	const input = "\xEF\xBB\xBF: comment\n" +
+		"data:first line\n" +
+		"data: second line\n" +
+		"id: 42\n" +
+		"retry: 1000\n" +
+		"unknown: field\n" +
+		"\n" +
+		"event: no data\n" +
+		"\n" +
+		"data\n" +
+		"\n"
+
+	want := []SSEEvent{
+		{Data: []byte("first line\nsecond line"), ID: "42", Retry: 1000},
+		{Data: []byte(""), ID: "42"},
+	}
+
+	got := decodeAll(t, input)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestSSEDecoderEventWithoutTrailingNewline(t *testing.T) {
+	want := []SSEEvent{
+		{Event: "message_stop", Data: []byte(`{"type":"message_stop"}`), Unterminated: true},
+	}
+
+	got := decodeAll(t, "event: message_stop\ndata: {\"type\":\"message_stop\"}")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestSSEDecoderLongLine(t *testing.T) {
+	// Longer than the buffer of the underlying bufio.Reader
+	data := strings.Repeat("x", 10000)
+	want := []SSEEvent{
+		{Event: "long", Data: []byte(data)},
+		{Event: "short", Data: []byte("1")},
+	}
+
+	got := decodeAll(t, "event: long\r\ndata: "+data+"\r\n\r\nevent: short\r\ndata: 1\r\n\r\n")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() got %d events, want %d", len(got), len(want))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 )
 
@@ -16,8 +17,12 @@ const messagesSuffix = "/messages"
 
 var (
 	ErrContentFieldsMisused             = errors.New("can't use both Content and ContentBlocks properties simultaneously")
+	ErrToolResultFieldsMisused          = errors.New("can't use both ToolResultText and ToolResultContent properties simultaneously")
+	ErrSystemFieldsMisused              = errors.New("can't use both System and SystemBlocks properties simultaneously")
 	ErrChatCompletionStreamNotSupported = errors.New("streaming is not supported with this method, please use CreateMessageStream") //nolint:lll
 	ErrModelNotAvailable                = errors.New("this model is not available for Anthropic Messages API")
+	ErrInvalidTemperature               = errors.New("temperature must be between 0 and 1")
+	ErrInvalidTopP                      = errors.New("top_p must be between 0 and 1")
 )
 
 const (
@@ -25,6 +30,12 @@ const (
 	ImageContentObjectType      = "image"
 	ToolUseContentObjectType    = "tool_use"
 	ToolResultContentObjectType = "tool_result"
+
+	ThinkingContentObjectType         = "thinking"
+	RedactedThinkingContentObjectType = "redacted_thinking"
+
+	// DocumentContentObjectType is used in the content of tool results
+	DocumentContentObjectType = "document"
 )
 
 // ContentBlock is used to provide the [InputMessage] with multiple input or input other than a simple string
@@ -37,45 +48,155 @@ type ContentBlock struct {
 	// For Image type
 	Source ImageSource `json:"source,omitempty"`
 
-	// For Tool Use type
+	// CacheControl marks the end of a cacheable prompt prefix. Not used for response content
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+
+	// For Tool Use type. Use [ContentBlock.DecodeInput] or [ToolInput] to decode the input into a Go type
 	ID    string                 `json:"id,omitempty"`
 	Name  string                 `json:"name,omitempty"`
 	Input map[string]interface{} `json:"input,omitempty"`
-
-	// For Tool Result type
-	ToolUseId         string            `json:"tool_use_id,omitempty"`
-	IsError           bool              `json:"is_error,omitempty"`
-	ToolResultContent ToolResultContent `json:"content,omitempty"`
+	// RawInput is the input exactly as received from the API. When set, it is sent and decoded instead of Input,
+	// so use [ContentBlock.SetInput] to change the input of a received block
+	RawInput json.RawMessage `json:"-"`
+
+	// For Tool Result type. ToolResultText is used to pass just a string as the result,
+	// ToolResultContent is used to pass multiple blocks and/or blocks other than text, like images.
+	// See [ToolResultText] and [ToolResultBlocks]
+	ToolUseId         string              `json:"tool_use_id,omitempty"`
+	IsError           bool                `json:"is_error,omitempty"`
+	ToolResultText    string              `json:"-"`
+	ToolResultContent []ToolResultContent `json:"-"`
+
+	// For Thinking type. Thinking blocks must be passed back unchanged in multi-turn conversations
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// For Redacted Thinking type. Data is encrypted thinking content
+	Data string `json:"data,omitempty"`
 }
 
 func (cb ContentBlock) MarshalJSON() ([]byte, error) {
 	type alias ContentBlock
 	temp := struct {
 		alias
-		Source            *ImageSource       `json:"source,omitempty"`
-		ToolResultContent *ToolResultContent `json:"content,omitempty"`
+		Source    *ImageSource `json:"source,omitempty"`
+		Content   any          `json:"content,omitempty"`
+		Input     any          `json:"input,omitempty"`
+		Thinking  *string      `json:"thinking,omitempty"`
+		Signature *string      `json:"signature,omitempty"`
 	}{
 		alias: alias(cb),
 	}
 
+	// Blocks received from the API are replayed exactly, so required fields are kept even when empty
+	switch cb.Type {
+	case ToolUseContentObjectType:
+		switch {
+		case len(cb.RawInput) > 0:
+			temp.Input = cb.RawInput
+		case cb.Input != nil:
+			temp.Input = cb.Input
+		default:
+			temp.Input = struct{}{}
+		}
+	case ThinkingContentObjectType:
+		temp.Thinking = &cb.Thinking
+		temp.Signature = &cb.Signature
+	default:
+		if len(cb.RawInput) > 0 {
+			temp.Input = cb.RawInput
+		} else if len(cb.Input) > 0 {
+			temp.Input = cb.Input
+		}
+		if cb.Thinking != "" {
+			temp.Thinking = &cb.Thinking
+		}
+		if cb.Signature != "" {
+			temp.Signature = &cb.Signature
+		}
+	}
+
 	if cb.Source != (ImageSource{}) {
 		temp.Source = &cb.Source
 	}
 
-	if cb.ToolResultContent != (ToolResultContent{}) {
-		temp.ToolResultContent = &cb.ToolResultContent
+	switch {
+	case cb.ToolResultText != "" && cb.ToolResultContent != nil:
+		return nil, ErrToolResultFieldsMisused
+	case cb.ToolResultText != "":
+		temp.Content = cb.ToolResultText
+	case len(cb.ToolResultContent) > 0:
+		temp.Content = cb.ToolResultContent
 	}
 
 	return json.Marshal(temp)
 }
 
+func (cb *ContentBlock) UnmarshalJSON(data []byte) error {
+	type alias ContentBlock
+	temp := struct {
+		*alias
+		Input   json.RawMessage `json:"input,omitempty"`
+		Content json.RawMessage `json:"content,omitempty"`
+	}{
+		alias: (*alias)(cb),
+	}
+
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	cb.ToolResultText = ""
+	cb.ToolResultContent = nil
+	if len(temp.Content) > 0 && string(temp.Content) != "null" {
+		// Content of a tool result is either a string or an array of blocks
+		if temp.Content[0] == '"' {
+			if err := json.Unmarshal(temp.Content, &cb.ToolResultText); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(temp.Content, &cb.ToolResultContent); err != nil {
+			return err
+		}
+	}
+
+	cb.Input = nil
+	cb.RawInput = nil
+	if len(temp.Input) == 0 || string(temp.Input) == "null" {
+		return nil
+	}
+
+	cb.RawInput = temp.Input
+	return json.Unmarshal(temp.Input, &cb.Input)
+}
+
+// ToolResultText creates a tool_result block with a string as the result of the tool_use block with toolUseID
+func ToolResultText(toolUseID, text string) ContentBlock {
+	return ContentBlock{
+		Type:           ToolResultContentObjectType,
+		ToolUseId:      toolUseID,
+		ToolResultText: text,
+	}
+}
+
+// ToolResultBlocks creates a tool_result block with text, image and document blocks as the result
+// of the tool_use block with toolUseID
+func ToolResultBlocks(toolUseID string, blocks ...ToolResultContent) ContentBlock {
+	return ContentBlock{
+		Type:              ToolResultContentObjectType,
+		ToolUseId:         toolUseID,
+		ToolResultContent: blocks,
+	}
+}
+
+// ToolResultContent is a single block of the content of a tool result
 type ToolResultContent struct {
 	Type string `json:"type"`
 
 	// For Text type
 	Text string `json:"text,omitempty"`
 
-	// For Image type
+	// For Image and Document types. Documents use [DocumentPDFMediaType] with [ImageSourceType] ("base64") source,
+	// or [DocumentPlainTextMediaType] with [TextSourceType] source
 	Source ImageSource `json:"source,omitempty"`
 }
 
@@ -95,6 +216,21 @@ func (trs ToolResultContent) MarshalJSON() ([]byte, error) {
 	return json.Marshal(temp)
 }
 
+const EphemeralCacheControlType = "ephemeral"
+
+const (
+	CacheTTL5Minutes = "5m"
+	CacheTTL1Hour    = "1h"
+)
+
+// CacheControl creates a prompt caching breakpoint. The whole prompt prefix up to and including
+// the block with CacheControl is cached: tools first, then system prompt, then messages.
+type CacheControl struct {
+	Type string `json:"type"`
+	// TTL is a lifetime of the cache entry, [CacheTTL5Minutes] by default
+	TTL string `json:"ttl,omitempty"`
+}
+
 const ImageSourceType = "base64"
 const (
 	ImageJPEGMediaType = "image/jpeg"
@@ -103,6 +239,13 @@ const (
 	ImageWebPMediaType = "image/webp"
 )
 
+const TextSourceType = "text"
+
+const (
+	DocumentPDFMediaType       = "application/pdf"
+	DocumentPlainTextMediaType = "text/plain"
+)
+
 type ImageSource struct {
 	Type      string `json:"type"`
 	MediaType string `json:"media_type"`
@@ -142,10 +285,13 @@ func (m InputMessage) MarshalJSON() ([]byte, error) {
 }
 
 func (m *InputMessage) UnmarshalJSON(bs []byte) error {
-	msg := InputMessage{}
+	textMsg := struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{}
 
-	if err := json.Unmarshal(bs, &msg); err == nil {
-		*m = msg
+	if err := json.Unmarshal(bs, &textMsg); err == nil {
+		*m = InputMessage{Role: textMsg.Role, Content: textMsg.Content}
 		return nil
 	}
 
@@ -180,22 +326,121 @@ type MessageRequest struct {
 	Messages  []InputMessage `json:"messages"`
 	MaxTokens int            `json:"max_tokens"`
 
-	Temperature   int                     `json:"temperature,omitempty"`
+	// Temperature and TopP are pointers, so that an explicit 0 is sent. Use [Float] to set them
+	Temperature   *float64                `json:"temperature,omitempty"`
 	StopSequences []string                `json:"stop_sequences,omitempty"`
 	Metadata      *MessageRequestMetadata `json:"metadata,omitempty"`
 	Stream        bool                    `json:"stream,omitempty"`
 	System        string                  `json:"system,omitempty"`
 	TopK          int                     `json:"top_k,omitempty"`
-	TopP          int                     `json:"top_p,omitempty"`
+	TopP          *float64                `json:"top_p,omitempty"`
+
+	// SystemCacheControl sets a cache breakpoint at the end of the system prompt. With it, System is sent
+	// as a single text block, because cache_control can only be set on blocks
+	SystemCacheControl *CacheControl `json:"-"`
+
+	// SystemBlocks is used instead of System to pass the system prompt as text blocks, e.g. with [CacheControl].
+	// Note that SystemBlocks cannot be used simultaneously with System and SystemCacheControl fields.
+	SystemBlocks []ContentBlock `json:"-"`
 
 	Tools      []Tool      `json:"tools,omitempty"`
 	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	Thinking *ThinkingConfig `json:"thinking,omitempty"`
+}
+
+const (
+	EnabledThinkingType  = "enabled"
+	DisabledThinkingType = "disabled"
+)
+
+// ThinkingConfig enables extended thinking. BudgetTokens must be at least 1024 and less than MaxTokens of the request
+type ThinkingConfig struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+}
+
+// Float returns a pointer to v. It is used to set optional numeric fields of [MessageRequest], like Temperature and TopP
+func Float(v float64) *float64 {
+	return &v
+}
+
+// checkUnitInterval returns err if value is set and is not between 0 and 1
+func checkUnitInterval(value *float64, err error) error {
+	if value != nil && (*value < 0 || *value > 1) {
+		return fmt.Errorf("%w, got %g", err, *value)
+	}
+	return nil
+}
+
+// systemPrompt returns the value of "system" field of the request: either a string or a list of blocks
+func (r MessageRequest) systemPrompt() (any, error) {
+	if r.SystemBlocks != nil && (r.System != "" || r.SystemCacheControl != nil) {
+		return nil, ErrSystemFieldsMisused
+	}
+
+	if len(r.SystemBlocks) > 0 {
+		return r.SystemBlocks, nil
+	}
+	if r.System == "" {
+		return nil, nil
+	}
+
+	if r.SystemCacheControl != nil {
+		return []ContentBlock{{Type: TextContentObjectType, Text: r.System, CacheControl: r.SystemCacheControl}}, nil
+	}
+	return r.System, nil
+}
+
+func (r MessageRequest) MarshalJSON() ([]byte, error) {
+	system, err := r.systemPrompt()
+	if err != nil {
+		return nil, err
+	}
+
+	type alias MessageRequest
+	temp := struct {
+		alias
+		System any `json:"system,omitempty"`
+	}{
+		alias:  alias(r),
+		System: system,
+	}
+
+	return json.Marshal(temp)
+}
+
+func (r *MessageRequest) UnmarshalJSON(bs []byte) error {
+	type alias MessageRequest
+	temp := struct {
+		*alias
+		System json.RawMessage `json:"system,omitempty"`
+	}{
+		alias: (*alias)(r),
+	}
+
+	if err := json.Unmarshal(bs, &temp); err != nil {
+		return err
+	}
+
+	r.System, r.SystemBlocks, r.SystemCacheControl = "", nil, nil
+	if len(temp.System) == 0 || string(temp.System) == "null" {
+		return nil
+	}
+
+	if temp.System[0] == '"' {
+		return json.Unmarshal(temp.System, &r.System)
+	}
+	return json.Unmarshal(temp.System, &r.SystemBlocks)
 }
 
 type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description,omitempty"`
 	InputSchema map[string]interface{} `json:"input_schema"`
+
+	// CacheControl on the last tool caches all tool definitions
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 const ObjectToolInputSchemaType = "object"
@@ -234,11 +479,29 @@ type MessageResponse struct {
 	StopReason   StopReason     `json:"stop_reason"`
 	StopSequence string         `json:"stop_sequence,omitempty"`
 	Usage        Usage          `json:"usage"`
+
+	// RateLimit is parsed from the response headers. It is empty for messages received from a stream
+	RateLimit RateLimitInfo `json:"-"`
+}
+
+func (r *MessageResponse) setHeader(header http.Header) {
+	r.RateLimit = newRateLimitInfo(header)
 }
 
 type Usage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// Tokens written to and read from the prompt cache. They are not included in InputTokens
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
 }
 
 // CreateMessage - API call to Anthropic Messages API to create a message completion
@@ -248,11 +511,24 @@ func (c *Client) CreateMessage(ctx context.Context, request MessageRequest) (res
 		return
 	}
 
-	req, err := c.newRequest(context.Background(), http.MethodPost, c.fullURL(messagesSuffix), withBody(request))
+	if !c.config.DisableRequestValidation {
+		if err = request.Validate(); err != nil {
+			return
+		}
+	}
+
+	if err = c.checkModel(ctx, request.Model); err != nil {
+		return
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(messagesSuffix), withBody(request))
 	if err != nil {
 		return
 	}
 
 	err = c.sendRequest(req, &response)
+	if err == nil && c.rateLimiter != nil {
+		c.rateLimiter.charge(response.Usage)
+	}
 	return
 }
@@ -0,0 +1,166 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrMessageIncomplete = errors.New("stream ended before the message was completed")
+
+// MessageAccumulator rebuilds a complete [MessageResponse] from [MessageStreamEvent]s, the same as
+// the one returned by [Client.CreateMessage] for a non-streaming request.
+//
+// [MessageStream] already uses MessageAccumulator internally, see [MessageStream.FinalMessage].
+type MessageAccumulator struct {
+	message     MessageResponse
+	partialJSON []strings.Builder
+
+	started bool
+	stopped bool
+	err     error
+}
+
+// NewMessageAccumulator creates an empty MessageAccumulator
+func NewMessageAccumulator() *MessageAccumulator {
+	return &MessageAccumulator{}
+}
+
+// Add applies the event to the accumulated message. Error events and events that do not fit the message
+// make Add return an error, which is also returned by all later calls to Add and [MessageAccumulator.Message]
+func (a *MessageAccumulator) Add(event MessageStreamEvent) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	a.err = a.add(event)
+	return a.err
+}
+
+func (a *MessageAccumulator) add(event MessageStreamEvent) error {
+	if event.Type == ErrStreamEventType {
+		return &APIError{
+			Type:    event.Error.Type,
+			Message: event.Error.Message,
+		}
+	}
+
+	if event.Type != MessageStartStreamEventType && event.Type != PingStreamEventType && !a.started {
+		return fmt.Errorf("unexpected event of type \"%s\" before message_start", event.Type)
+	}
+
+	switch event.Type {
+	case MessageStartStreamEventType:
+		a.message = event.Message
+		a.message.Content = append(make([]ContentBlock, 0, len(event.Message.Content)), event.Message.Content...)
+		a.partialJSON = make([]strings.Builder, len(a.message.Content))
+		a.started = true
+
+	case ContentBlockStartStreamEventType:
+		if event.Index != len(a.message.Content) {
+			return fmt.Errorf("unexpected content block index %d, expected %d", event.Index, len(a.message.Content))
+		}
+		a.message.Content = append(a.message.Content, event.ContentBlock)
+		a.partialJSON = append(a.partialJSON, strings.Builder{})
+
+	case ContentBlockDeltaStreamEventType:
+		if event.Index < 0 || event.Index >= len(a.message.Content) {
+			return fmt.Errorf("delta for unknown content block index %d", event.Index)
+		}
+
+		switch event.Delta.Type {
+		case InputJSONDeltaType:
+			a.partialJSON[event.Index].WriteString(event.Delta.PartialJSON)
+		case ThinkingDeltaType:
+			a.message.Content[event.Index].Thinking += event.Delta.Thinking
+		case SignatureDeltaType:
+			a.message.Content[event.Index].Signature = event.Delta.Signature
+		default:
+			a.message.Content[event.Index].Text += event.Delta.Text
+		}
+
+	case ContentBlockStopStreamEventType:
+		if event.Index < 0 || event.Index >= len(a.message.Content) {
+			return fmt.Errorf("stop for unknown content block index %d", event.Index)
+		}
+
+		block := &a.message.Content[event.Index]
+		if block.Type != ToolUseContentObjectType {
+			break
+		}
+
+		rawInput := json.RawMessage("{}")
+		if partial := a.partialJSON[event.Index].String(); partial != "" {
+			rawInput = json.RawMessage(partial)
+		}
+
+		input := map[string]interface{}{}
+		if err := json.Unmarshal(rawInput, &input); err != nil {
+			return fmt.Errorf("invalid input of tool_use content block %d: %w", event.Index, err)
+		}
+		block.Input = input
+		block.RawInput = rawInput
+
+	case MessageDeltaStreamEventType:
+		a.message.StopReason = event.MessageDelta.StopReason
+		a.message.StopSequence = event.MessageDelta.StopSequence
+		if event.Usage != nil {
+			updateUsage(&a.message.Usage, *event.Usage)
+		}
+
+	case MessageStopStreamEventType:
+		a.stopped = true
+	}
+
+	return nil
+}
+
+// Done reports whether the message_stop event has been added
+func (a *MessageAccumulator) Done() bool {
+	return a.stopped
+}
+
+// Message returns the accumulated message. It returns [ErrMessageIncomplete] if the message_stop event has not been added yet
+func (a *MessageAccumulator) Message() (MessageResponse, error) {
+	if a.err != nil {
+		return a.message, a.err
+	}
+
+	if !a.stopped {
+		return a.message, ErrMessageIncomplete
+	}
+
+	return a.message, nil
+}
+
+// FinalMessage reads the rest of the stream and returns the complete message, including the content of events
+// that have already been received with Recv() and RecvAll().
+func (stream *MessageStream) FinalMessage() (MessageResponse, error) {
+	for !stream.accumulator.Done() && stream.accumulator.err == nil {
+		_, err := stream.RecvAll()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stream.accumulator.message, err
+		}
+	}
+
+	return stream.accumulator.Message()
+}
+
+// updateUsage updates usage of a message with the cumulative usage of its message_delta event
+func updateUsage(usage *Usage, delta Usage) {
+	usage.OutputTokens = delta.OutputTokens
+	if delta.InputTokens > 0 {
+		usage.InputTokens = delta.InputTokens
+	}
+	if delta.CacheCreationInputTokens > 0 {
+		usage.CacheCreationInputTokens = delta.CacheCreationInputTokens
+	}
+	if delta.CacheReadInputTokens > 0 {
+		usage.CacheReadInputTokens = delta.CacheReadInputTokens
+	}
+}
@@ -0,0 +1,267 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const messageBatchesSuffix = "/messages/batches"
+
+var ErrUntrustedResultsURL = errors.New("results URL of the batch does not point to the base URL of the client")
+
+type MessageBatchProcessingStatus string
+
+const (
+	MessageBatchInProgress MessageBatchProcessingStatus = "in_progress"
+	MessageBatchCanceling  MessageBatchProcessingStatus = "canceling"
+	MessageBatchEnded      MessageBatchProcessingStatus = "ended"
+)
+
+// BatchRequest is a single request of a message batch. CustomID is used to match results with requests
+type BatchRequest struct {
+	CustomID string         `json:"custom_id"`
+	Params   MessageRequest `json:"params"`
+}
+
+type MessageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// MessageBatch describes a batch created with [Client.CreateMessageBatch]
+type MessageBatch struct {
+	ID                string                       `json:"id"`
+	Type              string                       `json:"type"`
+	ProcessingStatus  MessageBatchProcessingStatus `json:"processing_status"`
+	RequestCounts     MessageBatchRequestCounts    `json:"request_counts"`
+	CreatedAt         time.Time                    `json:"created_at"`
+	ExpiresAt         time.Time                    `json:"expires_at"`
+	EndedAt           *time.Time                   `json:"ended_at"`
+	CancelInitiatedAt *time.Time                   `json:"cancel_initiated_at"`
+	ArchivedAt        *time.Time                   `json:"archived_at"`
+	// ResultsURL is set when the processing of the batch has ended. See [Client.GetMessageBatchResults]
+	ResultsURL string `json:"results_url"`
+}
+
+type DeletedMessageBatch struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// ListMessageBatchesRequest holds pagination parameters of [Client.ListMessageBatches]
+type ListMessageBatchesRequest = ListParams
+
+// MessageBatchList is a single page of batches. Pass LastID as AfterID of the next request to get the next page,
+// or use [Client.MessageBatchesPager] instead
+type MessageBatchList = Page[MessageBatch]
+
+// CreateMessageBatch - API call to Anthropic Message Batches API to create a batch of message requests processed asynchronously
+func (c *Client) CreateMessageBatch(ctx context.Context, requests []BatchRequest) (batch MessageBatch, err error) {
+	body := struct {
+		Requests []BatchRequest `json:"requests"`
+	}{
+		Requests: requests,
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(messageBatchesSuffix), withBody(body))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &batch)
+	return
+}
+
+// GetMessageBatch retrieves the current state of the batch
+func (c *Client) GetMessageBatch(ctx context.Context, batchID string) (batch MessageBatch, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.messageBatchURL(batchID, ""))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &batch)
+	return
+}
+
+// ListMessageBatches lists batches of the workspace, most recently created first
+func (c *Client) ListMessageBatches(ctx context.Context, request ListMessageBatchesRequest) (MessageBatchList, error) {
+	return listPage[MessageBatch](ctx, c, messageBatchesSuffix, request)
+}
+
+// MessageBatchesPager returns a [Pager] over all batches of the workspace, starting from the page described by request
+func (c *Client) MessageBatchesPager(request ListMessageBatchesRequest) *Pager[MessageBatch] {
+	return newPager[MessageBatch](c, messageBatchesSuffix, request)
+}
+
+// CancelMessageBatch initiates the cancellation of the batch. The batch is in "canceling" state until its processing ends
+func (c *Client) CancelMessageBatch(ctx context.Context, batchID string) (batch MessageBatch, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.messageBatchURL(batchID, "/cancel"))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &batch)
+	return
+}
+
+// DeleteMessageBatch deletes the batch. Only batches that have ended can be deleted
+func (c *Client) DeleteMessageBatch(ctx context.Context, batchID string) (deleted DeletedMessageBatch, err error) {
+	req, err := c.newRequest(ctx, http.MethodDelete, c.messageBatchURL(batchID, ""))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &deleted)
+	return
+}
+
+func (c *Client) messageBatchURL(batchID, suffix string) string {
+	return c.fullURL(fmt.Sprintf("%s/%s%s", messageBatchesSuffix, url.PathEscape(batchID), suffix))
+}
+
+type MessageBatchResultType string
+
+const (
+	SucceededBatchResultType MessageBatchResultType = "succeeded"
+	ErroredBatchResultType   MessageBatchResultType = "errored"
+	CanceledBatchResultType  MessageBatchResultType = "canceled"
+	ExpiredBatchResultType   MessageBatchResultType = "expired"
+)
+
+// MessageBatchResult is a result of a single request of the batch
+type MessageBatchResult struct {
+	CustomID string             `json:"custom_id"`
+	Result   BatchRequestResult `json:"result"`
+}
+
+type BatchRequestResult struct {
+	Type MessageBatchResultType `json:"type"`
+
+	// For succeeded type
+	Message *MessageResponse `json:"message,omitempty"`
+
+	// For errored type
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+// Err returns the [*APIError] of an errored result and nil otherwise
+func (r BatchRequestResult) Err() error {
+	if r.Type != ErroredBatchResultType || r.Error == nil || r.Error.Error == nil {
+		return nil
+	}
+	return r.Error.Error
+}
+
+// MessageBatchResults reads results of the batch line by line, without loading the whole results file into memory
+type MessageBatchResults struct {
+	reader   *bufio.Reader
+	response *http.Response
+}
+
+// GetMessageBatchResults streams results of the batch from its ResultsURL, which is set only after the processing
+// of the batch has ended. Use [Client.GetMessageBatch] to get the current state of the batch first.
+// If ResultsURL is empty, the results are requested from the default results path of the batch.
+// Results may be in any order, use CustomID to match them with requests.
+//
+// The API key is sent with the request, so [ErrUntrustedResultsURL] is returned if the scheme or host of ResultsURL
+// differ from BaseUrl of the client.
+//
+// The returned [MessageBatchResults] must be closed after use.
+func (c *Client) GetMessageBatchResults(ctx context.Context, batch MessageBatch) (*MessageBatchResults, error) {
+	resultsURL, err := c.messageBatchResultsURL(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, resultsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
+		return nil, handleErrorResponse(resp)
+	}
+
+	return &MessageBatchResults{
+		reader:   bufio.NewReader(resp.Body),
+		response: resp,
+	}, nil
+}
+
+func (c *Client) messageBatchResultsURL(batch MessageBatch) (string, error) {
+	if batch.ResultsURL == "" {
+		return c.messageBatchURL(batch.ID, "/results"), nil
+	}
+
+	resultsURL, err := url.Parse(batch.ResultsURL)
+	if err != nil {
+		return "", err
+	}
+	baseURL, err := url.Parse(c.config.BaseUrl)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(resultsURL.Scheme, baseURL.Scheme) || !strings.EqualFold(resultsURL.Host, baseURL.Host) {
+		return "", fmt.Errorf("%w: %s", ErrUntrustedResultsURL, batch.ResultsURL)
+	}
+	return batch.ResultsURL, nil
+}
+
+// Recv returns the next result. It returns [io.EOF] when all results have been read
+func (r *MessageBatchResults) Recv() (result MessageBatchResult, err error) {
+	for {
+		line, readErr := r.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+
+		if len(line) > 0 {
+			err = json.Unmarshal(line, &result)
+			return
+		}
+
+		if readErr != nil {
+			err = readErr
+			return
+		}
+	}
+}
+
+// All returns an iterator over the remaining results. The results are closed when the loop ends
+func (r *MessageBatchResults) All() iter.Seq2[MessageBatchResult, error] {
+	return func(yield func(MessageBatchResult, error) bool) {
+		defer r.Close()
+
+		for {
+			result, err := r.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(result, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (r *MessageBatchResults) Close() error {
+	return r.response.Body.Close()
+}
@@ -0,0 +1,172 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mockBatchJSON = `{
+	"id": "msgbatch_1",
+	"type": "message_batch",
+	"processing_status": "ended",
+	"request_counts": {"processing": 0, "succeeded": 1, "errored": 1, "canceled": 0, "expired": 1},
+	"created_at": "2024-09-24T18:37:24.100435Z",
+	"expires_at": "2024-09-25T18:37:24.100435Z",
+	"ended_at": "2024-09-24T18:40:00Z",
+	"cancel_initiated_at": null,
+	"archived_at": null,
+	"results_url": "https://api.anthropic.com/v1/messages/batches/msgbatch_1/results"
+}`
+
+func newBatchServer(t *testing.T) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Requests []BatchRequest `json:"requests"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Len(t, body.Requests, 2)
+		assert.Equal(t, "first", body.Requests[0].CustomID)
+		assert.Equal(t, "mock", body.Requests[0].Params.Model)
+
+		fmt.Fprint(w, mockBatchJSON)
+	})
+	mux.HandleFunc("GET /messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "msgbatch_0", r.URL.Query().Get("after_id"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+
+		fmt.Fprintf(w, `{"data":[%s],"has_more":false,"first_id":"msgbatch_1","last_id":"msgbatch_1"}`, mockBatchJSON)
+	})
+	mux.HandleFunc("GET /messages/batches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "msgbatch_1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"not_found_error","message":"Not found"}}`)
+			return
+		}
+		fmt.Fprint(w, mockBatchJSON)
+	})
+	mux.HandleFunc("POST /messages/batches/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, mockBatchJSON)
+	})
+	mux.HandleFunc("DELETE /messages/batches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"%s","type":"message_batch_deleted"}`, r.PathValue("id"))
+	})
+	results := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-jsonl")
+		fmt.Fprintln(w, `{"custom_id":"first","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":5}}}}`)
+		fmt.Fprintln(w, `{"custom_id":"second","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"Bad"}}}}`)
+		fmt.Fprint(w, `{"custom_id":"third","result":{"type":"expired"}}`)
+	}
+	mux.HandleFunc("GET /messages/batches/{id}/results", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "msgbatch_1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"not_found_error","message":"Not found"}}`)
+			return
+		}
+		results(w, r)
+	})
+	// ResultsURL of a batch does not have to be under the batch path
+	mux.HandleFunc("GET /results/{file}", results)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	return NewClientWithConfig(config)
+}
+
+func TestMessageBatchLifecycle(t *testing.T) {
+	client := newBatchServer(t)
+	ctx := context.Background()
+
+	batch, err := client.CreateMessageBatch(ctx, []BatchRequest{
+		{CustomID: "first", Params: MessageRequest{Model: "mock", MaxTokens: 100}},
+		{CustomID: "second", Params: MessageRequest{Model: "mock", MaxTokens: 100}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "msgbatch_1", batch.ID)
+	assert.Equal(t, MessageBatchEnded, batch.ProcessingStatus)
+	assert.Equal(t, 1, batch.RequestCounts.Expired)
+	assert.NotNil(t, batch.EndedAt)
+	assert.Nil(t, batch.CancelInitiatedAt)
+
+	batch, err = client.GetMessageBatch(ctx, "msgbatch_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "msgbatch_1", batch.ID)
+
+	_, err = client.GetMessageBatch(ctx, "unknown")
+	assert.True(t, IsNotFound(err))
+
+	list, err := client.ListMessageBatches(ctx, ListMessageBatchesRequest{AfterID: "msgbatch_0", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, "msgbatch_1", list.LastID)
+
+	_, err = client.CancelMessageBatch(ctx, "msgbatch_1")
+	assert.NoError(t, err)
+
+	deleted, err := client.DeleteMessageBatch(ctx, "msgbatch_1")
+	assert.NoError(t, err)
+	assert.Equal(t, DeletedMessageBatch{ID: "msgbatch_1", Type: "message_batch_deleted"}, deleted)
+}
+
+func TestMessageBatchResults(t *testing.T) {
+	client := newBatchServer(t)
+
+	batch := MessageBatch{ID: "msgbatch_2", ResultsURL: client.config.BaseUrl + "/results/msgbatch_2.jsonl"}
+	results, err := client.GetMessageBatchResults(context.Background(), batch)
+	assert.NoError(t, err)
+
+	var got []MessageBatchResult
+	for result, err := range results.All() {
+		assert.NoError(t, err)
+		got = append(got, result)
+	}
+
+	assert.Len(t, got, 3)
+
+	assert.Equal(t, "first", got[0].CustomID)
+	assert.Equal(t, SucceededBatchResultType, got[0].Result.Type)
+	assert.Equal(t, "Hello", got[0].Result.Message.Content[0].Text)
+	assert.NoError(t, got[0].Result.Err())
+
+	assert.Equal(t, ErroredBatchResultType, got[1].Result.Type)
+	assert.True(t, IsInvalidRequest(got[1].Result.Err()))
+
+	assert.Equal(t, "third", got[2].CustomID)
+	assert.Equal(t, ExpiredBatchResultType, got[2].Result.Type)
+}
+
+func TestMessageBatchResultsWithoutURL(t *testing.T) {
+	client := newBatchServer(t)
+
+	results, err := client.GetMessageBatchResults(context.Background(), MessageBatch{ID: "msgbatch_1"})
+	assert.NoError(t, err)
+	defer results.Close()
+
+	result, err := results.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", result.CustomID)
+}
+
+func TestMessageBatchResultsUntrustedURL(t *testing.T) {
+	client := newBatchServer(t)
+
+	for _, resultsURL := range []string{
+		"https://attacker.example.com/results/msgbatch_2.jsonl",
+		strings.Replace(client.config.BaseUrl, "http://", "https://", 1) + "/results/msgbatch_2.jsonl",
+	} {
+		_, err := client.GetMessageBatchResults(context.Background(), MessageBatch{ID: "msgbatch_2", ResultsURL: resultsURL})
+		assert.ErrorIs(t, err, ErrUntrustedResultsURL)
+	}
+}
@@ -0,0 +1,117 @@
+package anthropic
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultBulkConcurrency = 4
+
+// BulkOptions configures [Client.CreateMessages]
+type BulkOptions struct {
+	// Concurrency is the maximum number of requests sent at the same time. Defaults to 4
+	Concurrency int
+	// StopOnError cancels outstanding requests after the first failed request
+	StopOnError bool
+	// OnProgress is called after each request completes. Calls are never concurrent
+	OnProgress func(progress BulkProgress)
+}
+
+// BulkResult is a result of a single request of [Client.CreateMessages]
+type BulkResult struct {
+	Response MessageResponse
+	Err      error
+}
+
+// BulkProgress is passed to [BulkOptions.OnProgress] after each request completes
+type BulkProgress struct {
+	// Index is the index of the request that has just completed
+	Index  int
+	Result BulkResult
+
+	Completed int
+	Failed    int
+	Total     int
+}
+
+// BulkResponse holds results of [Client.CreateMessages] in the order of requests
+type BulkResponse struct {
+	Results []BulkResult
+	// Usage is the sum of usage of all successful requests
+	Usage Usage
+}
+
+// CreateMessages sends many requests with [Client.CreateMessage] concurrently. Errors of single requests are
+// returned in their [BulkResult]s. Requests that have not been completed when ctx is done, or after the first
+// error with StopOnError, get the error of the cancelled context.
+//
+// The returned error is non-nil only if ctx is done or a request has failed with StopOnError
+func (c *Client) CreateMessages(ctx context.Context, requests []MessageRequest, opts BulkOptions) (BulkResponse, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		response = BulkResponse{Results: make([]BulkResult, len(requests))}
+		progress = BulkProgress{Total: len(requests)}
+		firstErr error
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+
+	indexes := make(chan int)
+	for range min(concurrency, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				resp, err := c.CreateMessage(ctx, requests[i])
+				result := BulkResult{Response: resp, Err: err}
+
+				mu.Lock()
+				response.Results[i] = result
+				progress.Completed++
+				if err != nil {
+					progress.Failed++
+					if opts.StopOnError && firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					response.Usage.add(resp.Usage)
+				}
+				if opts.OnProgress != nil {
+					progress.Index = i
+					progress.Result = result
+					opts.OnProgress(progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	sent := 0
+	for sent < len(requests) && ctx.Err() == nil {
+		select {
+		case indexes <- sent:
+			sent++
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := sent; i < len(requests); i++ {
+		response.Results[i].Err = ctx.Err()
+	}
+
+	if firstErr != nil {
+		return response, firstErr
+	}
+	return response, ctx.Err()
+}
@@ -0,0 +1,125 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockBulkClient answers each request with its model name as the text, and fails requests with "fail" model
+func mockBulkClient(inFlight, maxInFlight *atomic.Int32) *Client {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				seen := maxInFlight.Load()
+				if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			var request MessageRequest
+			json.NewDecoder(req.Body).Decode(&request) //nolint:errcheck
+
+			if request.Model == "fail" {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","error":{"type":"invalid_request_error","message":"Bad"}}`)),
+					Header:     make(http.Header),
+				}
+			}
+
+			body := fmt.Sprintf(`{"content":[{"type":"text","text":"%s"}],"usage":{"input_tokens":2,"output_tokens":3}}`, request.Model)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+
+	return NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+}
+
+func TestCreateMessages(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	client := mockBulkClient(&inFlight, &maxInFlight)
+
+	requests := make([]MessageRequest, 10)
+	for i := range requests {
+		requests[i] = mockMessageRequest(fmt.Sprint(i))
+	}
+	requests[3].Model = "fail"
+
+	var progressCalls int
+	resp, err := client.CreateMessages(context.Background(), requests, BulkOptions{
+		Concurrency: 3,
+		OnProgress: func(progress BulkProgress) {
+			progressCalls++
+			assert.Equal(t, 10, progress.Total)
+			assert.Equal(t, progressCalls, progress.Completed)
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 10, progressCalls)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+	assert.Len(t, resp.Results, 10)
+	for i, result := range resp.Results {
+		if i == 3 {
+			assert.True(t, IsInvalidRequest(result.Err))
+			continue
+		}
+		assert.NoError(t, result.Err)
+		assert.Equal(t, fmt.Sprint(i), result.Response.Content[0].Text)
+	}
+	assert.Equal(t, Usage{InputTokens: 18, OutputTokens: 27}, resp.Usage)
+}
+
+func TestCreateMessagesStopOnError(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	client := mockBulkClient(&inFlight, &maxInFlight)
+
+	requests := make([]MessageRequest, 20)
+	for i := range requests {
+		requests[i] = mockMessageRequest(fmt.Sprint(i))
+	}
+	requests[0].Model = "fail"
+
+	resp, err := client.CreateMessages(context.Background(), requests, BulkOptions{
+		Concurrency: 1,
+		StopOnError: true,
+	})
+	assert.True(t, IsInvalidRequest(err))
+	assert.True(t, IsInvalidRequest(resp.Results[0].Err))
+	assert.ErrorIs(t, resp.Results[19].Err, context.Canceled)
+}
+
+func TestCreateMessagesContextCanceled(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	client := mockBulkClient(&inFlight, &maxInFlight)
+
+	requests := make([]MessageRequest, 100)
+	for i := range requests {
+		requests[i] = mockMessageRequest(fmt.Sprint(i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	resp, err := client.CreateMessages(ctx, requests, BulkOptions{Concurrency: 2})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, resp.Results[99].Err, context.DeadlineExceeded)
+}
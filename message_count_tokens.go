@@ -0,0 +1,49 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+)
+
+const countTokensSuffix = "/messages/count_tokens"
+
+// countTokensRequest holds the fields of [MessageRequest] accepted by the token counting endpoint
+type countTokensRequest struct {
+	Model      string         `json:"model"`
+	Messages   []InputMessage `json:"messages"`
+	System     any            `json:"system,omitempty"`
+	Tools      []Tool         `json:"tools,omitempty"`
+	ToolChoice *ToolChoice    `json:"tool_choice,omitempty"`
+
+	Thinking *ThinkingConfig `json:"thinking,omitempty"`
+}
+
+type TokenCount struct {
+	InputTokens int `json:"input_tokens"`
+}
+
+// CountMessageTokens - API call to count the number of input tokens of a message request, including messages,
+// system prompt and tools, without creating a message. Fields of the request that do not affect input tokens are ignored.
+func (c *Client) CountMessageTokens(ctx context.Context, request MessageRequest) (count TokenCount, err error) {
+	system, err := request.systemPrompt()
+	if err != nil {
+		return
+	}
+
+	body := countTokensRequest{
+		Model:      request.Model,
+		Messages:   request.Messages,
+		System:     system,
+		Tools:      request.Tools,
+		ToolChoice: request.ToolChoice,
+		Thinking:   request.Thinking,
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(countTokensSuffix), withBody(body))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &count)
+	return
+}
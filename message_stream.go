@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 )
 
@@ -31,16 +32,89 @@ type MessageStreamEvent struct {
 	// For content_block_delta type
 	Delta MessageStreamDelta `json:"delta,omitempty"`
 
+	// For message_delta type. Both fields are decoded from the "delta" and "usage" objects of the event.
+	// Output tokens in Usage are cumulative for the whole message
+	MessageDelta MessageDelta `json:"-"`
+	Usage        *Usage       `json:"usage,omitempty"`
+
 	// For error type
 	Error MessageStreamError `json:"error,omitempty"`
 }
 
+// UnmarshalJSON decodes the "delta" object of the event into Delta or MessageDelta, depending on the type of the event
+func (e *MessageStreamEvent) UnmarshalJSON(bs []byte) error {
+	type alias MessageStreamEvent
+	temp := struct {
+		*alias
+		Delta json.RawMessage `json:"delta,omitempty"`
+	}{
+		alias: (*alias)(e),
+	}
+
+	if err := json.Unmarshal(bs, &temp); err != nil {
+		return err
+	}
+
+	if len(temp.Delta) == 0 {
+		return nil
+	}
+
+	if e.Type == MessageDeltaStreamEventType {
+		return json.Unmarshal(temp.Delta, &e.MessageDelta)
+	}
+	return json.Unmarshal(temp.Delta, &e.Delta)
+}
+
+func (e MessageStreamEvent) MarshalJSON() ([]byte, error) {
+	type alias MessageStreamEvent
+	temp := struct {
+		alias
+		Message      *MessageResponse    `json:"message,omitempty"`
+		ContentBlock *ContentBlock       `json:"content_block,omitempty"`
+		Delta        any                 `json:"delta,omitempty"`
+		Error        *MessageStreamError `json:"error,omitempty"`
+	}{
+		alias: alias(e),
+	}
+
+	switch e.Type {
+	case MessageStartStreamEventType:
+		temp.Message = &e.Message
+	case ContentBlockStartStreamEventType:
+		temp.ContentBlock = &e.ContentBlock
+	case ContentBlockDeltaStreamEventType:
+		temp.Delta = e.Delta
+	case MessageDeltaStreamEventType:
+		temp.Delta = e.MessageDelta
+	case ErrStreamEventType:
+		temp.Error = &e.Error
+	}
+
+	return json.Marshal(temp)
+}
+
+const (
+	TextDeltaType      = "text_delta"
+	InputJSONDeltaType = "input_json_delta"
+	ThinkingDeltaType  = "thinking_delta"
+	SignatureDeltaType = "signature_delta"
+)
+
 type MessageStreamDelta struct {
 	Type string `json:"type"`
 
 	Text string `json:"text,omitempty"`
 
 	PartialJSON string `json:"partial_json,omitempty"`
+
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// MessageDelta carries top-level changes of the message sent with the message_delta event
+type MessageDelta struct {
+	StopReason   StopReason `json:"stop_reason"`
+	StopSequence string     `json:"stop_sequence,omitempty"`
 }
 
 type MessageStreamError struct {
@@ -50,6 +124,9 @@ type MessageStreamError struct {
 
 type MessageStream struct {
 	*streamReader
+
+	// RateLimit is parsed from the headers of the stream response
+	RateLimit RateLimitInfo
 }
 
 // CreateMessageStream — API call to create a message w/ streaming
@@ -61,7 +138,18 @@ type MessageStream struct {
 // receive data from stream.
 func (c *Client) CreateMessageStream(ctx context.Context, request MessageRequest) (stream *MessageStream, err error) {
 	request.Stream = true
-	req, err := c.newRequest(context.Background(), http.MethodPost, c.fullURL(messagesSuffix), withBody(request))
+
+	if !c.config.DisableRequestValidation {
+		if err = request.Validate(); err != nil {
+			return
+		}
+	}
+
+	if err = c.checkModel(ctx, request.Model); err != nil {
+		return
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(messagesSuffix), withBody(request))
 	if err != nil {
 		return
 	}
@@ -73,5 +161,6 @@ func (c *Client) CreateMessageStream(ctx context.Context, request MessageRequest
 
 	return &MessageStream{
 		streamReader: resp,
+		RateLimit:    newRateLimitInfo(resp.response.Header),
 	}, nil
 }
@@ -0,0 +1,97 @@
+package anthropic
+
+import "context"
+
+// StreamHandlers are callbacks called by [Client.StreamMessage] for events of the stream. All of them are optional.
+// Callbacks are called sequentially from the goroutine that called StreamMessage.
+type StreamHandlers struct {
+	OnMessageStart      func(message MessageResponse)
+	OnContentBlockStart func(index int, block ContentBlock)
+	// OnText is called for every "text_delta" event
+	OnText func(index int, text string)
+	// OnToolInputDelta is called for every "input_json_delta" event with a fragment of tool_use input JSON
+	OnToolInputDelta func(index int, partialJSON string)
+	// OnThinking is called for every "thinking_delta" event
+	OnThinking func(index int, thinking string)
+	// OnContentBlockStop receives the complete content block, including the parsed Input of a tool_use block
+	OnContentBlockStop func(index int, block ContentBlock)
+	OnMessageDelta     func(delta MessageDelta, usage Usage)
+	// OnError is called once if the stream fails, with the same error that is returned by StreamMessage
+	OnError func(err error)
+	// OnFinal receives the complete message after the message_stop event
+	OnFinal func(message MessageResponse)
+}
+
+// StreamMessage creates a message with streaming, like [Client.CreateMessageStream], and calls handlers
+// for the events of the stream until it ends. It returns the complete message, the same as the one passed to OnFinal.
+func (c *Client) StreamMessage(ctx context.Context, request MessageRequest, handlers StreamHandlers) (MessageResponse, error) {
+	stream, err := c.CreateMessageStream(ctx, request)
+	if err != nil {
+		return handlers.fail(MessageResponse{}, err)
+	}
+
+	for event, err := range stream.Events() {
+		if err != nil {
+			return handlers.fail(stream.accumulator.message, err)
+		}
+
+		handlers.handle(event, stream.accumulator)
+	}
+
+	message, err := stream.accumulator.Message()
+	if err != nil {
+		return handlers.fail(message, err)
+	}
+
+	if handlers.OnFinal != nil {
+		handlers.OnFinal(message)
+	}
+	return message, nil
+}
+
+func (h StreamHandlers) handle(event MessageStreamEvent, accumulator *MessageAccumulator) {
+	switch event.Type {
+	case MessageStartStreamEventType:
+		if h.OnMessageStart != nil {
+			h.OnMessageStart(event.Message)
+		}
+	case ContentBlockStartStreamEventType:
+		if h.OnContentBlockStart != nil {
+			h.OnContentBlockStart(event.Index, event.ContentBlock)
+		}
+	case ContentBlockDeltaStreamEventType:
+		switch event.Delta.Type {
+		case TextDeltaType:
+			if h.OnText != nil {
+				h.OnText(event.Index, event.Delta.Text)
+			}
+		case InputJSONDeltaType:
+			if h.OnToolInputDelta != nil {
+				h.OnToolInputDelta(event.Index, event.Delta.PartialJSON)
+			}
+		case ThinkingDeltaType:
+			if h.OnThinking != nil {
+				h.OnThinking(event.Index, event.Delta.Thinking)
+			}
+		}
+	case ContentBlockStopStreamEventType:
+		if h.OnContentBlockStop != nil && event.Index < len(accumulator.message.Content) {
+			h.OnContentBlockStop(event.Index, accumulator.message.Content[event.Index])
+		}
+	case MessageDeltaStreamEventType:
+		if h.OnMessageDelta != nil {
+			var usage Usage
+			if event.Usage != nil {
+				usage = *event.Usage
+			}
+			h.OnMessageDelta(event.MessageDelta, usage)
+		}
+	}
+}
+
+func (h StreamHandlers) fail(message MessageResponse, err error) (MessageResponse, error) {
+	if h.OnError != nil {
+		h.OnError(err)
+	}
+	return message, err
+}
@@ -0,0 +1,62 @@
+package anthropic
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// Events returns an iterator over all events of the stream, the same as received with RecvAll().
+// An error event of the stream is yielded together with its [*APIError].
+//
+// The stream is closed when the loop ends, including with break, so Close() does not need to be called.
+func (stream *MessageStream) Events() iter.Seq2[MessageStreamEvent, error] {
+	return func(yield func(MessageStreamEvent, error) bool) {
+		defer stream.Close()
+
+		for {
+			event, err := stream.RecvAll()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(event, err)
+				return
+			}
+
+			if event.Type == ErrStreamEventType {
+				yield(event, &APIError{
+					Type:    event.Error.Type,
+					Message: event.Error.Message,
+				})
+				return
+			}
+
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TextDeltas returns an iterator over the text of "text_delta" events of the stream.
+//
+// The stream is closed when the loop ends, including with break, so Close() does not need to be called.
+func (stream *MessageStream) TextDeltas() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for event, err := range stream.Events() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+
+			if event.Type != ContentBlockDeltaStreamEventType || event.Delta.Type != TextDeltaType {
+				continue
+			}
+
+			if !yield(event.Delta.Text, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mockToolUseStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-3-5-sonnet-20240620","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" the weather."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"location\": \"San Fra"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"ncisco, CA\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"type":"message_delta","stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":89}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func mockStreamClient(body string) *Client {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("Content-Type", "text/event-stream")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header:     header,
+			}
+		},
+	}
+
+	return NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+}
+
+func TestMessageStreamFinalMessage(t *testing.T) {
+	client := mockStreamClient(mockToolUseStream)
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	// Events received before calling FinalMessage are also accumulated
+	delta, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "Let me check", delta.Text)
+
+	message, err := stream.FinalMessage()
+	assert.NoError(t, err)
+
+	assert.Equal(t, MessageResponse{
+		ID:    "msg_1",
+		Type:  "message",
+		Role:  MessageRoleAssistant,
+		Model: "claude-3-5-sonnet-20240620",
+		Content: []ContentBlock{
+			{
+				Type: TextContentObjectType,
+				Text: "Let me check the weather.",
+			},
+			{
+				Type:     ToolUseContentObjectType,
+				ID:       "toolu_1",
+				Name:     "get_weather",
+				Input:    map[string]interface{}{"location": "San Francisco, CA"},
+				RawInput: json.RawMessage(`{"location": "San Francisco, CA"}`),
+			},
+		},
+		StopReason: StopReasonToolUser,
+		Usage: Usage{
+			InputTokens:  25,
+			OutputTokens: 89,
+		},
+	}, message)
+}
+
+func TestMessageAccumulatorErrors(t *testing.T) {
+	acc := NewMessageAccumulator()
+	assert.Error(t, acc.Add(MessageStreamEvent{Type: ContentBlockDeltaStreamEventType}))
+
+	acc = NewMessageAccumulator()
+	assert.NoError(t, acc.Add(MessageStreamEvent{Type: MessageStartStreamEventType}))
+	_, err := acc.Message()
+	assert.ErrorIs(t, err, ErrMessageIncomplete)
+
+	err = acc.Add(MessageStreamEvent{
+		Type:  ErrStreamEventType,
+		Error: MessageStreamError{Type: OverloadedErrorType, Message: "Overloaded"},
+	})
+	assert.True(t, IsOverloaded(err))
+	_, err = acc.Message()
+	assert.True(t, IsOverloaded(err))
+}
+
+func TestMessageDeltaEventUnmarshal(t *testing.T) {
+	const data = `{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"output_tokens":15}}`
+
+	var event MessageStreamEvent
+	assert.NoError(t, json.Unmarshal([]byte(data), &event))
+
+	assert.Equal(t, MessageDeltaStreamEventType, event.Type)
+	assert.Equal(t, StopReasonMaxTokens, event.MessageDelta.StopReason)
+	assert.Equal(t, &Usage{OutputTokens: 15}, event.Usage)
+	assert.Equal(t, MessageStreamDelta{}, event.Delta)
+
+	bs, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"input_tokens":0,"output_tokens":15}}`, string(bs))
+}
+
+func TestContentBlockDeltaEventRoundTrip(t *testing.T) {
+	const data = `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"a\":"}}`
+
+	var event MessageStreamEvent
+	assert.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, MessageStreamDelta{Type: InputJSONDeltaType, PartialJSON: `{"a":`}, event.Delta)
+	assert.Equal(t, MessageDelta{}, event.MessageDelta)
+
+	bs, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(bs))
+}
+
+func TestMessageStreamTruncated(t *testing.T) {
+	const body = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+`
+	client := mockStreamClient(body)
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+
+	_, err = stream.FinalMessage()
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+}
+
+func TestMessageStreamTruncatedMidEvent(t *testing.T) {
+	const body = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_st`
+	client := mockStreamClient(body)
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	event, err := stream.RecvAll()
+	assert.NoError(t, err)
+	assert.Equal(t, MessageStartStreamEventType, event.Type)
+
+	_, err = stream.RecvAll()
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+
+	_, err = stream.RecvAll()
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+}
+
+func TestMessageStreamEndsWithEOF(t *testing.T) {
+	client := mockStreamClient("event: message_start\r\ndata: {\"type\":\"message_start\",\"message\":{}}\r\n\r\n" +
+		": keep-alive comment\r\n\r\n" +
+		"event: message_stop\r\ndata: {\"type\":\"message_stop\"}")
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	event, err := stream.RecvAll()
+	assert.NoError(t, err)
+	assert.Equal(t, MessageStartStreamEventType, event.Type)
+
+	event, err = stream.RecvAll()
+	assert.NoError(t, err)
+	assert.Equal(t, MessageStopStreamEventType, event.Type)
+
+	_, err = stream.RecvAll()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestMessageStreamEventNameMismatch(t *testing.T) {
+	client := mockStreamClient("event: message_stop\ndata: {\"type\":\"ping\"}\n\n")
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.RecvAll()
+	assert.ErrorIs(t, err, ErrStreamEventMismatch)
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func mockTrackedStream(t *testing.T, body string) (*MessageStream, *closeTracker) {
+	t.Helper()
+
+	tracker := &closeTracker{Reader: bytes.NewBufferString(body)}
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("Content-Type", "text/event-stream")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       tracker,
+				Header:     header,
+			}
+		},
+	}
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+
+	stream, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	return stream, tracker
+}
+
+func TestMessageStreamTextDeltas(t *testing.T) {
+	stream, tracker := mockTrackedStream(t, mockToolUseStream)
+
+	var text string
+	for delta, err := range stream.TextDeltas() {
+		assert.NoError(t, err)
+		text += delta
+	}
+
+	assert.Equal(t, "Let me check the weather.", text)
+	assert.True(t, tracker.closed)
+}
+
+func TestMessageStreamEventsBreak(t *testing.T) {
+	stream, tracker := mockTrackedStream(t, mockToolUseStream)
+
+	for event, err := range stream.Events() {
+		assert.NoError(t, err)
+		assert.Equal(t, MessageStartStreamEventType, event.Type)
+		break
+	}
+
+	assert.True(t, tracker.closed)
+}
+
+func TestMessageStreamEventsError(t *testing.T) {
+	stream, tracker := mockTrackedStream(t, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+
+	var errs []error
+	for _, err := range stream.Events() {
+		errs = append(errs, err)
+	}
+
+	assert.Len(t, errs, 1)
+	assert.True(t, IsOverloaded(errs[0]))
+	assert.True(t, tracker.closed)
+}
+
+func TestStreamMessageHandlers(t *testing.T) {
+	client := mockStreamClient(mockToolUseStream)
+
+	var (
+		text       string
+		partial    string
+		stopped    []ContentBlock
+		stopReason StopReason
+		final      MessageResponse
+	)
+	message, err := client.StreamMessage(context.Background(), mockMessageRequest("mock"), StreamHandlers{
+		OnText: func(index int, delta string) {
+			text += delta
+		},
+		OnToolInputDelta: func(index int, partialJSON string) {
+			partial += partialJSON
+		},
+		OnContentBlockStop: func(index int, block ContentBlock) {
+			stopped = append(stopped, block)
+		},
+		OnMessageDelta: func(delta MessageDelta, usage Usage) {
+			stopReason = delta.StopReason
+		},
+		OnError: func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+		OnFinal: func(message MessageResponse) {
+			final = message
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Let me check the weather.", text)
+	assert.Equal(t, `{"location": "San Francisco, CA"}`, partial)
+	assert.Len(t, stopped, 2)
+	assert.Equal(t, map[string]interface{}{"location": "San Francisco, CA"}, stopped[1].Input)
+	assert.Equal(t, StopReasonToolUser, stopReason)
+	assert.Equal(t, message, final)
+}
+
+func TestStreamMessageHandlersError(t *testing.T) {
+	client := mockStreamClient("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n")
+
+	var handledErr error
+	_, err := client.StreamMessage(context.Background(), mockMessageRequest("mock"), StreamHandlers{
+		OnError: func(err error) {
+			handledErr = err
+		},
+		OnFinal: func(message MessageResponse) {
+			t.Error("OnFinal called for a failed stream")
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+	assert.Equal(t, err, handledErr)
+}
+
+func TestMessageStreamFailureStatus(t *testing.T) {
+	client := mockErrorClient(http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+
+	_, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "invalid x-api-key", apiErr.Message)
+	assert.Equal(t, "req_123", apiErr.RequestID)
+	assert.True(t, IsAuthentication(err))
+}
+
+func TestMessageStreamUnexpectedContentType(t *testing.T) {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("Content-Type", "application/json")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"id":"msg_1"}`)),
+				Header:     header,
+			}
+		},
+	}
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+
+	_, err := client.CreateMessageStream(context.Background(), mockMessageRequest("mock"))
+	assert.ErrorIs(t, err, ErrUnexpectedContentType)
+
+	var reqErr *RequestError
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, `{"id":"msg_1"}`, string(reqErr.Body))
+}
+
+func TestMessageStreamHonorsContext(t *testing.T) {
+	server := newHangingServer(t, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n")
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	client := NewClientWithConfig(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.CreateMessageStream(ctx, mockMessageRequest("mock"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	event, err := stream.RecvAll()
+	assert.NoError(t, err)
+	assert.Equal(t, MessageStartStreamEventType, event.Type)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = stream.RecvAll()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestMessageStreamThinking(t *testing.T) {
+	const body = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"usage":{"input_tokens":5,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me think"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":" about it."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"EqQBCgIYAhIM"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"encrypted"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"42"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":30}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	client := mockStreamClient(body)
+
+	var thinking string
+	message, err := client.StreamMessage(context.Background(), mockMessageRequest("mock"), StreamHandlers{
+		OnThinking: func(index int, delta string) {
+			thinking += delta
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Let me think about it.", thinking)
+	assert.Equal(t, []ContentBlock{
+		{Type: ThinkingContentObjectType, Thinking: "Let me think about it.", Signature: "EqQBCgIYAhIM"},
+		{Type: RedactedThinkingContentObjectType, Data: "encrypted"},
+		{Type: TextContentObjectType, Text: "42"},
+	}, message.Content)
+}
@@ -1,12 +1,30 @@
 package anthropic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func mockMessageRequest(model string) MessageRequest {
+	return MessageRequest{
+		Model:     model,
+		MaxTokens: 100,
+		Messages: []InputMessage{
+			{Role: MessageRoleUser, Content: "Hello"},
+		},
+	}
+}
+
 func TestMessageWithTextOmitEmpty(t *testing.T) {
 	json1, err := json.Marshal(
 		MessageRequest{
@@ -96,9 +114,11 @@ func TestMessageWithToolResultOmitEmpty(t *testing.T) {
 							Type:      "tool_result",
 							ToolUseId: "tool_use_id",
 							IsError:   true,
-							ToolResultContent: ToolResultContent{
-								Type: "text",
-								Text: "tool result content text",
+							ToolResultContent: []ToolResultContent{
+								{
+									Type: "text",
+									Text: "tool result content text",
+								},
 							},
 						},
 					},
@@ -108,7 +128,7 @@ func TestMessageWithToolResultOmitEmpty(t *testing.T) {
 	)
 	assert.NoError(t, err)
 
-	const expectedJSON4 = `{"model":"mock","messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool_use_id","is_error":true,"content":{"type":"text","text":"tool result content text"}}]}],"max_tokens":0}`
+	const expectedJSON4 = `{"model":"mock","messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool_use_id","is_error":true,"content":[{"type":"text","text":"tool result content text"}]}]}],"max_tokens":0}`
 
 	assert.Equal(t, expectedJSON4, string(json4))
 
@@ -123,12 +143,14 @@ func TestMessageWithToolResultOmitEmpty(t *testing.T) {
 							Type:      "tool_result",
 							ToolUseId: "tool_use_id",
 							IsError:   false,
-							ToolResultContent: ToolResultContent{
-								Type: "image",
-								Source: ImageSource{
-									Type:      ImageSourceType,
-									MediaType: ImagePNGMediaType,
-									Data:      "data",
+							ToolResultContent: []ToolResultContent{
+								{
+									Type: "image",
+									Source: ImageSource{
+										Type:      ImageSourceType,
+										MediaType: ImagePNGMediaType,
+										Data:      "data",
+									},
 								},
 							},
 						},
@@ -139,11 +161,55 @@ func TestMessageWithToolResultOmitEmpty(t *testing.T) {
 	)
 	assert.NoError(t, err)
 
-	const expectedJSON5 = `{"model":"mock","messages":[{"role":"assistant","content":[{"type":"tool_result","tool_use_id":"tool_use_id","content":{"type":"image","source":{"type":"base64","media_type":"image/png","data":"data"}}}]}],"max_tokens":0}`
+	const expectedJSON5 = `{"model":"mock","messages":[{"role":"assistant","content":[{"type":"tool_result","tool_use_id":"tool_use_id","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"data"}}]}]}],"max_tokens":0}`
 
 	assert.Equal(t, expectedJSON5, string(json5))
 }
 
+func TestToolResultContentRoundTrip(t *testing.T) {
+	messages := []InputMessage{
+		{
+			Role: MessageRoleUser,
+			ContentBlocks: []ContentBlock{
+				ToolResultText("toolu_1", "42"),
+				ToolResultBlocks("toolu_2",
+					ToolResultContent{Type: TextContentObjectType, Text: "Screenshot of the page"},
+					ToolResultContent{
+						Type:   ImageContentObjectType,
+						Source: ImageSource{Type: ImageSourceType, MediaType: ImagePNGMediaType, Data: "data"},
+					},
+					ToolResultContent{
+						Type:   DocumentContentObjectType,
+						Source: ImageSource{Type: TextSourceType, MediaType: DocumentPlainTextMediaType, Data: "Page text"},
+					},
+				),
+			},
+		},
+	}
+
+	bs, err := json.Marshal(messages)
+	assert.NoError(t, err)
+
+	const expectedJSON = `[{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"toolu_1","content":"42"},` +
+		`{"type":"tool_result","tool_use_id":"toolu_2","content":[` +
+		`{"type":"text","text":"Screenshot of the page"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"data"}},` +
+		`{"type":"document","source":{"type":"text","media_type":"text/plain","data":"Page text"}}]}]}]`
+	assert.Equal(t, expectedJSON, string(bs))
+
+	var decoded []InputMessage
+	assert.NoError(t, json.Unmarshal(bs, &decoded))
+	assert.Equal(t, messages, decoded)
+
+	_, err = json.Marshal(ContentBlock{
+		Type:              ToolResultContentObjectType,
+		ToolResultText:    "42",
+		ToolResultContent: []ToolResultContent{{Type: TextContentObjectType, Text: "42"}},
+	})
+	assert.ErrorIs(t, err, ErrToolResultFieldsMisused)
+}
+
 func TestMessageContentDuplicateError(t *testing.T) {
 	_, err := json.Marshal(
 		MessageRequest{
@@ -164,4 +230,355 @@ func TestMessageContentDuplicateError(t *testing.T) {
 	assert.Error(t, err)
 }
 
-// TODO: Tests for CreateRequest method with a mock server
+// newHangingServer starts a server that optionally writes the prefix of a stream and then hangs until the client goes away
+func newHangingServer(t *testing.T, streamPrefix string) *httptest.Server {
+	t.Helper()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck
+
+		if streamPrefix != "" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, streamPrefix)
+			w.(http.Flusher).Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	return server
+}
+
+func TestCreateMessageHonorsContext(t *testing.T) {
+	server := newHangingServer(t, "")
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	client := NewClientWithConfig(config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.CreateMessage(ctx, mockMessageRequest("mock"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages/count_tokens", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"model":"mock","messages":[{"role":"user","content":"Hello"}],"system":"Be brief","tools":[{"name":"tool","input_schema":{"type":"object"}}]}`, string(body))
+
+		fmt.Fprint(w, `{"input_tokens":42}`)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	client := NewClientWithConfig(config)
+
+	count, err := client.CountMessageTokens(context.Background(), MessageRequest{
+		Model:     "mock",
+		MaxTokens: 1000,
+		System:    "Be brief",
+		Messages: []InputMessage{
+			{Role: MessageRoleUser, Content: "Hello"},
+		},
+		Tools: []Tool{
+			{Name: "tool", InputSchema: map[string]interface{}{"type": "object"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count.InputTokens)
+}
+
+func TestMessageWithCacheControl(t *testing.T) {
+	bs, err := json.Marshal(
+		MessageRequest{
+			Model: "mock",
+			Messages: []InputMessage{
+				{
+					Role: MessageRoleUser,
+					ContentBlocks: []ContentBlock{
+						{
+							Type:         TextContentObjectType,
+							Text:         "long document",
+							CacheControl: &CacheControl{Type: EphemeralCacheControlType, TTL: CacheTTL1Hour},
+						},
+					},
+				},
+			},
+			Tools: []Tool{
+				{
+					Name:         "tool",
+					InputSchema:  map[string]interface{}{"type": "object"},
+					CacheControl: &CacheControl{Type: EphemeralCacheControlType},
+				},
+			},
+		},
+	)
+	assert.NoError(t, err)
+
+	const expectedJSON = `{"model":"mock","messages":[{"role":"user","content":[{"type":"text","text":"long document","cache_control":{"type":"ephemeral","ttl":"1h"}}]}],"max_tokens":0,"tools":[{"name":"tool","input_schema":{"type":"object"},"cache_control":{"type":"ephemeral"}}]}`
+
+	assert.Equal(t, expectedJSON, string(bs))
+}
+
+func TestUsageWithCacheTokens(t *testing.T) {
+	var resp MessageResponse
+	err := json.Unmarshal([]byte(`{"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":300,"cache_read_input_tokens":4000}}`), &resp)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Usage{
+		InputTokens:              10,
+		OutputTokens:             20,
+		CacheCreationInputTokens: 300,
+		CacheReadInputTokens:     4000,
+	}, resp.Usage)
+}
+
+func TestMessageWithSystemCacheControl(t *testing.T) {
+	request := MessageRequest{
+		Model:              "mock",
+		Messages:           []InputMessage{{Role: MessageRoleUser, Content: "Question"}},
+		MaxTokens:          100,
+		System:             "Long reference document",
+		SystemCacheControl: &CacheControl{Type: EphemeralCacheControlType},
+	}
+
+	bs, err := json.Marshal(request)
+	assert.NoError(t, err)
+
+	const expectedJSON = `{"model":"mock","messages":[{"role":"user","content":"Question"}],"max_tokens":100,"system":[{"type":"text","text":"Long reference document","cache_control":{"type":"ephemeral"}}]}`
+	assert.JSONEq(t, expectedJSON, string(bs))
+
+	request.SystemCacheControl = nil
+	bs, err = json.Marshal(request)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"system":"Long reference document"`)
+}
+
+func TestMessageWithSystemBlocksRoundTrip(t *testing.T) {
+	request := MessageRequest{
+		Model: "mock",
+		Messages: []InputMessage{
+			{Role: MessageRoleUser, Content: "Question"},
+			{Role: MessageRoleAssistant, ContentBlocks: []ContentBlock{{Type: TextContentObjectType, Text: "Answer"}}},
+		},
+		MaxTokens: 100,
+		SystemBlocks: []ContentBlock{
+			{Type: TextContentObjectType, Text: "You are a helpful assistant."},
+			{
+				Type:         TextContentObjectType,
+				Text:         "Long reference document",
+				CacheControl: &CacheControl{Type: EphemeralCacheControlType},
+			},
+		},
+	}
+
+	bs, err := json.Marshal(request)
+	assert.NoError(t, err)
+
+	const expectedJSON = `{"model":"mock","messages":[{"role":"user","content":"Question"},{"role":"assistant","content":[{"type":"text","text":"Answer"}]}],"max_tokens":100,"system":[{"type":"text","text":"You are a helpful assistant."},{"type":"text","text":"Long reference document","cache_control":{"type":"ephemeral"}}]}`
+	assert.JSONEq(t, expectedJSON, string(bs))
+
+	var decoded MessageRequest
+	assert.NoError(t, json.Unmarshal(bs, &decoded))
+	assert.Equal(t, request, decoded)
+
+	request.SystemBlocks = nil
+	request.System = "Be brief"
+	bs, err = json.Marshal(request)
+	assert.NoError(t, err)
+
+	decoded = MessageRequest{}
+	assert.NoError(t, json.Unmarshal(bs, &decoded))
+	assert.Equal(t, request, decoded)
+}
+
+func TestMessageSystemDuplicateError(t *testing.T) {
+	_, err := json.Marshal(
+		MessageRequest{
+			Model:        "mock",
+			System:       "system",
+			SystemBlocks: []ContentBlock{{Type: TextContentObjectType, Text: "system"}},
+		},
+	)
+	assert.ErrorIs(t, err, ErrSystemFieldsMisused)
+
+	_, err = json.Marshal(
+		MessageRequest{
+			Model:              "mock",
+			SystemCacheControl: &CacheControl{Type: EphemeralCacheControlType},
+			SystemBlocks:       []ContentBlock{{Type: TextContentObjectType, Text: "system"}},
+		},
+	)
+	assert.ErrorIs(t, err, ErrSystemFieldsMisused)
+}
+
+func TestInputMessageUnmarshal(t *testing.T) {
+	var message InputMessage
+	assert.NoError(t, json.Unmarshal([]byte(`{"role":"user","content":"Hello"}`), &message))
+	assert.Equal(t, InputMessage{Role: MessageRoleUser, Content: "Hello"}, message)
+
+	message = InputMessage{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"role":"user","content":[{"type":"text","text":"Hello"}]}`), &message))
+	assert.Equal(t, InputMessage{
+		Role:          MessageRoleUser,
+		ContentBlocks: []ContentBlock{{Type: TextContentObjectType, Text: "Hello"}},
+	}, message)
+}
+
+func TestMessageWithThinkingBlocksReplay(t *testing.T) {
+	const responseJSON = `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"thinking","thinking":"","signature":"sig"},{"type":"redacted_thinking","data":"encrypted"},{"type":"tool_use","id":"toolu_1","name":"get_time","input":{}}],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":2}}`
+
+	var resp MessageResponse
+	assert.NoError(t, json.Unmarshal([]byte(responseJSON), &resp))
+
+	bs, err := json.Marshal(
+		MessageRequest{
+			Model:     "mock",
+			MaxTokens: 2048,
+			Thinking:  &ThinkingConfig{Type: EnabledThinkingType, BudgetTokens: 1024},
+			Messages: []InputMessage{
+				{Role: MessageRoleUser, Content: "What time is it?"},
+				{Role: MessageRoleAssistant, ContentBlocks: resp.Content},
+			},
+		},
+	)
+	assert.NoError(t, err)
+
+	const expectedJSON = `{"model":"mock","messages":[{"role":"user","content":"What time is it?"},{"role":"assistant","content":[{"type":"thinking","thinking":"","signature":"sig"},{"type":"redacted_thinking","data":"encrypted"},{"type":"tool_use","id":"toolu_1","name":"get_time","input":{}}]}],"max_tokens":2048,"thinking":{"type":"enabled","budget_tokens":1024}}`
+	assert.Equal(t, expectedJSON, string(bs))
+}
+
+func TestMessageSamplingParameters(t *testing.T) {
+	bs, err := json.Marshal(MessageRequest{Model: "mock", Temperature: Float(0), TopP: Float(0.9)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"model":"mock","messages":null,"max_tokens":0,"temperature":0,"top_p":0.9}`, string(bs))
+
+	// Requests with out of range values must fail before they are sent
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(&MockRoundTripper{
+			roundTripFunc: func(req *http.Request) *http.Response {
+				t.Errorf("unexpected request to %s", req.URL)
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+					Header:     make(http.Header),
+				}
+			},
+		}),
+	})
+
+	request := mockMessageRequest("mock")
+	request.Temperature = Float(1.5)
+	_, err = client.CreateMessage(context.Background(), request)
+	assert.ErrorIs(t, err, ErrInvalidTemperature)
+
+	request = mockMessageRequest("mock")
+	request.TopP = Float(-0.1)
+	_, err = client.CreateMessageStream(context.Background(), request)
+	assert.ErrorIs(t, err, ErrInvalidTopP)
+}
+
+func TestMessageRequestValidate(t *testing.T) {
+	assert.NoError(t, mockMessageRequest("mock").Validate())
+
+	request := MessageRequest{
+		Messages: []InputMessage{
+			{Role: MessageRoleUser, Content: "What's the weather?"},
+			{Role: MessageRoleAssistant, ContentBlocks: []ContentBlock{
+				{Type: ToolUseContentObjectType, ID: "toolu_1", Name: "get_weather"},
+			}},
+			{Role: MessageRoleUser, ContentBlocks: []ContentBlock{
+				{Type: ToolResultContentObjectType, ToolUseId: "toolu_1"},
+				{Type: ToolResultContentObjectType, ToolUseId: "toolu_2"},
+				{Type: ImageContentObjectType, Source: ImageSource{Type: ImageSourceType, MediaType: "image/bmp", Data: "..."}},
+			}},
+			{Role: MessageRoleUser, Content: "Hello"},
+		},
+		Tools:      []Tool{{Name: "get_weather"}},
+		ToolChoice: &ToolChoice{Type: ToolToolChoiceType, Name: "get_time"},
+	}
+
+	err := request.Validate()
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	paths := make([]string, len(validationErr.Errors))
+	for i, fieldErr := range validationErr.Errors {
+		paths[i] = fieldErr.Path
+	}
+	assert.Equal(t, []string{
+		"model",
+		"max_tokens",
+		"messages[2].content[1].tool_use_id",
+		"messages[2].content[2].source.media_type",
+		"messages[3].role",
+		"tool_choice.name",
+	}, paths)
+
+	assert.ErrorIs(t, err, ErrEmptyModel)
+	assert.ErrorIs(t, err, ErrInvalidMaxTokens)
+	assert.ErrorIs(t, err, ErrUnknownToolUseID)
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+	assert.ErrorIs(t, err, ErrRolesNotAlternating)
+	assert.ErrorIs(t, err, ErrUnknownToolChoice)
+	assert.Contains(t, err.Error(), `messages[2].content[1].tool_use_id: tool_result does not reference a preceding tool_use block: "toolu_2"`)
+}
+
+func TestMessageRequestValidateToolResultContent(t *testing.T) {
+	request := mockMessageRequest("mock")
+	request.Messages = []InputMessage{
+		{Role: MessageRoleUser, Content: "Take a screenshot"},
+		{Role: MessageRoleAssistant, ContentBlocks: []ContentBlock{
+			{Type: ToolUseContentObjectType, ID: "toolu_1", Name: "screenshot"},
+		}},
+		{Role: MessageRoleUser, ContentBlocks: []ContentBlock{
+			ToolResultBlocks("toolu_1",
+				ToolResultContent{
+					Type:   ImageContentObjectType,
+					Source: ImageSource{Type: ImageSourceType, MediaType: ImagePNGMediaType, Data: "data"},
+				},
+				ToolResultContent{
+					Type:   DocumentContentObjectType,
+					Source: ImageSource{Type: ImageSourceType, MediaType: "application/zip", Data: "data"},
+				},
+			),
+		}},
+	}
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, request.Validate(), &validationErr)
+	assert.Len(t, validationErr.Errors, 1)
+	assert.Equal(t, "messages[2].content[0].content[1].source", validationErr.Errors[0].Path)
+	assert.ErrorIs(t, validationErr, ErrUnsupportedDocument)
+}
+
+func TestMessageRequestValidationDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	config.DisableRequestValidation = true
+	client := NewClientWithConfig(config)
+
+	_, err := client.CreateMessage(context.Background(), MessageRequest{Model: "mock"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,214 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmptyModel             = errors.New("model is required")
+	ErrInvalidMaxTokens       = errors.New("max_tokens must be greater than 0")
+	ErrEmptyMessages          = errors.New("at least one message is required")
+	ErrInvalidRole            = errors.New("invalid message role")
+	ErrRolesNotAlternating    = errors.New("roles must alternate between user and assistant, starting with user")
+	ErrUnknownToolUseID       = errors.New("tool_result does not reference a preceding tool_use block")
+	ErrUnknownToolChoice      = errors.New("tool_choice references a tool that is not in tools")
+	ErrUnsupportedImageType   = errors.New("unsupported image media type")
+	ErrInvalidThinkingBudget  = errors.New("thinking budget_tokens must be at least 1024 and less than max_tokens")
+	ErrMissingToolChoiceName  = errors.New("tool_choice of type \"tool\" requires a name")
+	ErrUnsupportedImageSource = errors.New("unsupported image source type")
+	ErrUnsupportedDocument    = errors.New("unsupported document source")
+)
+
+const minThinkingBudgetTokens = 1024
+
+// FieldError is a single problem found by [MessageRequest.Validate]. Path is a JSON path of the invalid field,
+// e.g. "messages[1].content[0].tool_use_id"
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists all problems found by [MessageRequest.Validate]. Use [errors.Is] to check for a specific problem
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "invalid message request: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+type requestValidator struct {
+	errors []*FieldError
+}
+
+func (v *requestValidator) add(path string, err error) {
+	v.errors = append(v.errors, &FieldError{Path: path, Err: err})
+}
+
+// Validate checks the request for problems that would make Anthropic API reject it.
+// It returns a [*ValidationError] listing all of them, or nil if the request is valid.
+//
+// Validate is called by [Client.CreateMessage] and [Client.CreateMessageStream], unless
+// ClientConfig.DisableRequestValidation is set
+func (r MessageRequest) Validate() error {
+	v := &requestValidator{}
+
+	if r.Model == "" {
+		v.add("model", ErrEmptyModel)
+	}
+
+	if r.MaxTokens <= 0 {
+		v.add("max_tokens", ErrInvalidMaxTokens)
+	}
+
+	if err := checkUnitInterval(r.Temperature, ErrInvalidTemperature); err != nil {
+		v.add("temperature", err)
+	}
+
+	if err := checkUnitInterval(r.TopP, ErrInvalidTopP); err != nil {
+		v.add("top_p", err)
+	}
+
+	if _, err := r.systemPrompt(); err != nil {
+		v.add("system", err)
+	}
+
+	for i, block := range r.SystemBlocks {
+		if block.Type != TextContentObjectType {
+			v.add(fmt.Sprintf("system[%d].type", i), fmt.Errorf("system blocks must be of type \"text\", got \"%s\"", block.Type))
+		}
+	}
+
+	v.validateMessages(r.Messages)
+	v.validateToolChoice(r.ToolChoice, r.Tools)
+
+	if r.Thinking != nil && r.Thinking.Type == EnabledThinkingType &&
+		(r.Thinking.BudgetTokens < minThinkingBudgetTokens || (r.MaxTokens > 0 && r.Thinking.BudgetTokens >= r.MaxTokens)) {
+		v.add("thinking.budget_tokens", ErrInvalidThinkingBudget)
+	}
+
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+func (v *requestValidator) validateMessages(messages []InputMessage) {
+	if len(messages) == 0 {
+		v.add("messages", ErrEmptyMessages)
+		return
+	}
+
+	toolUseIDs := make(map[string]bool)
+
+	for i, message := range messages {
+		path := fmt.Sprintf("messages[%d]", i)
+
+		expectedRole := MessageRoleUser
+		if i%2 == 1 {
+			expectedRole = MessageRoleAssistant
+		}
+
+		switch message.Role {
+		case MessageRoleUser, MessageRoleAssistant:
+			if message.Role != expectedRole {
+				v.add(path+".role", ErrRolesNotAlternating)
+			}
+		default:
+			v.add(path+".role", fmt.Errorf("%w \"%s\"", ErrInvalidRole, message.Role))
+		}
+
+		if message.Content != "" && message.ContentBlocks != nil {
+			v.add(path+".content", ErrContentFieldsMisused)
+		}
+
+		for j, block := range message.ContentBlocks {
+			blockPath := fmt.Sprintf("%s.content[%d]", path, j)
+
+			switch block.Type {
+			case ImageContentObjectType:
+				v.validateImageSource(blockPath+".source", block.Source)
+			case ToolUseContentObjectType:
+				toolUseIDs[block.ID] = true
+			case ToolResultContentObjectType:
+				if !toolUseIDs[block.ToolUseId] {
+					v.add(blockPath+".tool_use_id", fmt.Errorf("%w: \"%s\"", ErrUnknownToolUseID, block.ToolUseId))
+				}
+				if block.ToolResultText != "" && block.ToolResultContent != nil {
+					v.add(blockPath+".content", ErrToolResultFieldsMisused)
+				}
+				for k, content := range block.ToolResultContent {
+					contentPath := fmt.Sprintf("%s.content[%d]", blockPath, k)
+
+					switch content.Type {
+					case ImageContentObjectType:
+						v.validateImageSource(contentPath+".source", content.Source)
+					case DocumentContentObjectType:
+						v.validateDocumentSource(contentPath+".source", content.Source)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (v *requestValidator) validateImageSource(path string, source ImageSource) {
+	if source.Type != ImageSourceType {
+		v.add(path+".type", fmt.Errorf("%w \"%s\"", ErrUnsupportedImageSource, source.Type))
+	}
+
+	switch source.MediaType {
+	case ImageJPEGMediaType, ImagePNGMediaType, ImageGIFMediaType, ImageWebPMediaType:
+	default:
+		v.add(path+".media_type", fmt.Errorf("%w \"%s\"", ErrUnsupportedImageType, source.MediaType))
+	}
+}
+
+func (v *requestValidator) validateDocumentSource(path string, source ImageSource) {
+	switch {
+	case source.Type == ImageSourceType && source.MediaType == DocumentPDFMediaType:
+	case source.Type == TextSourceType && source.MediaType == DocumentPlainTextMediaType:
+	default:
+		v.add(path, fmt.Errorf("%w: \"%s\" with media type \"%s\"", ErrUnsupportedDocument, source.Type, source.MediaType))
+	}
+}
+
+func (v *requestValidator) validateToolChoice(toolChoice *ToolChoice, tools []Tool) {
+	if toolChoice == nil || toolChoice.Type != ToolToolChoiceType {
+		return
+	}
+
+	if toolChoice.Name == "" {
+		v.add("tool_choice.name", ErrMissingToolChoiceName)
+		return
+	}
+
+	for _, tool := range tools {
+		if tool.Name == toolChoice.Name {
+			return
+		}
+	}
+	v.add("tool_choice.name", fmt.Errorf("%w: \"%s\"", ErrUnknownToolChoice, toolChoice.Name))
+}
@@ -0,0 +1,111 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const modelsSuffix = "/models"
+
+// ModelInfo describes a model available through Anthropic API
+type ModelInfo struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListModelsRequest holds pagination parameters of [Client.ListModels]
+type ListModelsRequest = ListParams
+
+// ModelList is a single page of models. Pass LastID as AfterID of the next request to get the next page,
+// or use [Client.ModelsPager] instead
+type ModelList = Page[ModelInfo]
+
+// ListModels lists available models, most recently released first
+func (c *Client) ListModels(ctx context.Context, request ListModelsRequest) (ModelList, error) {
+	return listPage[ModelInfo](ctx, c, modelsSuffix, request)
+}
+
+// ModelsPager returns a [Pager] over all available models, starting from the page described by request
+func (c *Client) ModelsPager(request ListModelsRequest) *Pager[ModelInfo] {
+	return newPager[ModelInfo](c, modelsSuffix, request)
+}
+
+// GetModel retrieves a model by its ID or alias, e.g. "claude-3-5-sonnet-latest"
+func (c *Client) GetModel(ctx context.Context, modelID string) (model ModelInfo, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(modelsSuffix+"/"+url.PathEscape(modelID)))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &model)
+	return
+}
+
+type modelCacheEntry struct {
+	available bool
+	expiresAt time.Time
+}
+
+// modelCache remembers which models are available, so each model is looked up with [Client.GetModel] at most once per TTL
+type modelCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]modelCacheEntry
+}
+
+func newModelCache(ttl time.Duration) *modelCache {
+	return &modelCache{
+		ttl:     ttl,
+		entries: make(map[string]modelCacheEntry),
+	}
+}
+
+func (mc *modelCache) get(model string, now time.Time) (available, found bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry, ok := mc.entries[model]
+	if !ok || now.After(entry.expiresAt) {
+		return false, false
+	}
+	return entry.available, true
+}
+
+func (mc *modelCache) set(model string, available bool, now time.Time) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.entries[model] = modelCacheEntry{
+		available: available,
+		expiresAt: now.Add(mc.ttl),
+	}
+}
+
+// checkModel returns [ErrModelNotAvailable] if the model cache is enabled and the model is unknown to Anthropic API
+func (c *Client) checkModel(ctx context.Context, model string) error {
+	if c.modelCache == nil {
+		return nil
+	}
+
+	available, found := c.modelCache.get(model, time.Now())
+	if !found {
+		_, err := c.GetModel(ctx, model)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+
+		available = err == nil
+		c.modelCache.set(model, available, time.Now())
+	}
+
+	if !available {
+		return fmt.Errorf("%w: \"%s\"", ErrModelNotAvailable, model)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newModelsServer(t *testing.T, lookups *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /models", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "claude-3-opus-20240229", r.URL.Query().Get("before_id"))
+		assert.Equal(t, "1", r.URL.Query().Get("limit"))
+
+		fmt.Fprint(w, `{"data":[{"type":"model","id":"claude-3-5-sonnet-20240620","display_name":"Claude 3.5 Sonnet","created_at":"2024-06-20T00:00:00Z"}],"has_more":true,"first_id":"claude-3-5-sonnet-20240620","last_id":"claude-3-5-sonnet-20240620"}`)
+	})
+	mux.HandleFunc("GET /models/{id}", func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		if r.PathValue("id") != Claude35SonnetModel {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"type":"model","id":"claude-3-5-sonnet-20240620","display_name":"Claude 3.5 Sonnet","created_at":"2024-06-20T00:00:00Z"}`)
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"msg_1"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListAndGetModels(t *testing.T) {
+	var lookups atomic.Int32
+	server := newModelsServer(t, &lookups)
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	client := NewClientWithConfig(config)
+
+	list, err := client.ListModels(context.Background(), ListModelsRequest{BeforeID: Claude3OpusModel, Limit: 1})
+	assert.NoError(t, err)
+	assert.True(t, list.HasMore)
+	assert.Equal(t, []ModelInfo{
+		{
+			ID:          Claude35SonnetModel,
+			Type:        "model",
+			DisplayName: "Claude 3.5 Sonnet",
+			CreatedAt:   time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC),
+		},
+	}, list.Data)
+
+	model, err := client.GetModel(context.Background(), Claude35SonnetModel)
+	assert.NoError(t, err)
+	assert.Equal(t, "Claude 3.5 Sonnet", model.DisplayName)
+}
+
+func TestCreateMessageModelValidation(t *testing.T) {
+	var lookups atomic.Int32
+	server := newModelsServer(t, &lookups)
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	config.ModelCacheTTL = time.Minute
+	client := NewClientWithConfig(config)
+
+	for range 2 {
+		_, err := client.CreateMessage(context.Background(), mockMessageRequest(Claude35SonnetModel))
+		assert.NoError(t, err)
+
+		_, err = client.CreateMessage(context.Background(), mockMessageRequest("claude-unknown"))
+		assert.ErrorIs(t, err, ErrModelNotAvailable)
+	}
+
+	_, err := client.CreateMessageStream(context.Background(), mockMessageRequest("claude-unknown"))
+	assert.ErrorIs(t, err, ErrModelNotAvailable)
+
+	// Each model is looked up only once
+	assert.Equal(t, int32(2), lookups.Load())
+}
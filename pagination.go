@@ -0,0 +1,98 @@
+package anthropic
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// ListParams are cursor pagination parameters shared by list endpoints. All fields are optional
+type ListParams struct {
+	// BeforeID returns the page of objects immediately before this object ID
+	BeforeID string
+	// AfterID returns the page of objects immediately after this object ID
+	AfterID string
+	// Limit is the number of objects per page, from 1 to 1000. API uses 20 by default
+	Limit int
+}
+
+// Page is a single page of objects returned by a list endpoint
+type Page[T any] struct {
+	Data    []T    `json:"data"`
+	HasMore bool   `json:"has_more"`
+	FirstID string `json:"first_id"`
+	LastID  string `json:"last_id"`
+}
+
+// Pager fetches consecutive pages of a list endpoint. If BeforeID is set in the initial [ListParams]
+// and AfterID is not, pages are fetched backwards, otherwise forwards.
+type Pager[T any] struct {
+	client *Client
+	suffix string
+	params ListParams
+	done   bool
+}
+
+func newPager[T any](client *Client, suffix string, params ListParams) *Pager[T] {
+	return &Pager[T]{
+		client: client,
+		suffix: suffix,
+		params: params,
+	}
+}
+
+// Next fetches the next page. It returns [io.EOF] after the last page has been fetched
+func (p *Pager[T]) Next(ctx context.Context) (Page[T], error) {
+	if p.done {
+		return Page[T]{}, io.EOF
+	}
+
+	page, err := listPage[T](ctx, p.client, p.suffix, p.params)
+	if err != nil {
+		return page, err
+	}
+
+	switch {
+	case !page.HasMore || page.FirstID == "" || page.LastID == "":
+		p.done = true
+	case p.params.BeforeID != "" && p.params.AfterID == "":
+		p.params.BeforeID = page.FirstID
+	default:
+		p.params.AfterID = page.LastID
+	}
+
+	return page, nil
+}
+
+// All returns an iterator over objects of all remaining pages, fetching them as needed
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			page, err := p.Next(ctx)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+
+			for _, item := range page.Data {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func listPage[T any](ctx context.Context, c *Client, suffix string, params ListParams) (page Page[T], err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.listURL(suffix, params.BeforeID, params.AfterID, params.Limit))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &page)
+	return
+}
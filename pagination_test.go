@@ -0,0 +1,88 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagedModelsServer serves models "m0" to "m{count-1}" with cursor pagination
+func newPagedModelsServer(t *testing.T, count int) *Client {
+	t.Helper()
+
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = "m" + strconv.Itoa(i)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		start, end := 0, len(ids)
+		if after := query.Get("after_id"); after != "" {
+			start = slices.Index(ids, after) + 1
+		}
+		if before := query.Get("before_id"); before != "" {
+			end = slices.Index(ids, before)
+			start = max(end-limit, 0)
+		}
+		end = min(end, start+limit)
+
+		page := Page[ModelInfo]{
+			HasMore: (query.Get("before_id") != "" && start > 0) || (query.Get("before_id") == "" && end < len(ids)),
+		}
+		for _, id := range ids[start:end] {
+			page.Data = append(page.Data, ModelInfo{ID: id})
+		}
+		if len(page.Data) > 0 {
+			page.FirstID = page.Data[0].ID
+			page.LastID = page.Data[len(page.Data)-1].ID
+		}
+
+		json.NewEncoder(w).Encode(page) //nolint:errcheck
+	}))
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = server.URL
+	return NewClientWithConfig(config)
+}
+
+func TestPagerAll(t *testing.T) {
+	client := newPagedModelsServer(t, 7)
+
+	var ids []string
+	for model, err := range client.ModelsPager(ListModelsRequest{Limit: 3}).All(context.Background()) {
+		assert.NoError(t, err)
+		ids = append(ids, model.ID)
+	}
+
+	assert.Equal(t, []string{"m0", "m1", "m2", "m3", "m4", "m5", "m6"}, ids)
+}
+
+func TestPagerNextBackwards(t *testing.T) {
+	client := newPagedModelsServer(t, 7)
+	pager := client.ModelsPager(ListModelsRequest{BeforeID: "m6", Limit: 4})
+
+	page, err := pager.Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "m2", page.FirstID)
+	assert.True(t, page.HasMore)
+
+	page, err = pager.Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "m0", page.FirstID)
+	assert.Equal(t, "m1", page.LastID)
+	assert.False(t, page.HasMore)
+
+	_, err = pager.Next(context.Background())
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,210 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit describes the state of one rate limit reported by Anthropic API
+type RateLimit struct {
+	// Limit is the maximum number of requests or tokens allowed within the rate limit window
+	Limit int
+	// Remaining is the number of requests or tokens left before being rate limited
+	Remaining int
+	// Reset is the time when the rate limit will be fully replenished. It is zero if the API did not report this limit
+	Reset time.Time
+}
+
+// Reported returns true if Anthropic API has sent headers for this rate limit
+func (rl RateLimit) Reported() bool {
+	return !rl.Reset.IsZero()
+}
+
+// RateLimitInfo is parsed from the "anthropic-ratelimit-*" headers of the response
+type RateLimitInfo struct {
+	Requests     RateLimit
+	Tokens       RateLimit
+	InputTokens  RateLimit
+	OutputTokens RateLimit
+}
+
+func newRateLimitInfo(header http.Header) RateLimitInfo {
+	return RateLimitInfo{
+		Requests:     parseRateLimit(header, "requests"),
+		Tokens:       parseRateLimit(header, "tokens"),
+		InputTokens:  parseRateLimit(header, "input-tokens"),
+		OutputTokens: parseRateLimit(header, "output-tokens"),
+	}
+}
+
+func parseRateLimit(header http.Header, kind string) RateLimit {
+	prefix := rateLimitHeaderBase + kind
+	limit, _ := strconv.Atoi(header.Get(prefix + "-limit"))
+	remaining, _ := strconv.Atoi(header.Get(prefix + "-remaining"))
+	reset, _ := time.Parse(time.RFC3339, header.Get(prefix+"-reset"))
+
+	return RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset,
+	}
+}
+
+func (info RateLimitInfo) limits() []RateLimit {
+	return []RateLimit{info.Requests, info.Tokens, info.InputTokens, info.OutputTokens}
+}
+
+// exhaustedUntil returns the latest reset time among the rate limits that have no remaining capacity
+func (info RateLimitInfo) exhaustedUntil() (until time.Time, exhausted bool) {
+	for _, rl := range info.limits() {
+		if !rl.Reported() || rl.Remaining > 0 {
+			continue
+		}
+
+		if rl.Reset.After(until) {
+			until = rl.Reset
+		}
+		exhausted = true
+	}
+
+	return until, exhausted
+}
+
+// RateLimiterConfig configures the client-side rate limiter. Requests are delayed before being sent
+// when the configured budgets or the limits reported by Anthropic API are exhausted.
+//
+// Zero budgets mean that only rate limit headers returned by Anthropic API are used.
+type RateLimiterConfig struct {
+	RequestsPerMinute int
+	// TokensPerMinute limits the sum of input and output tokens reported in [Usage] of responses and streamed messages
+	TokensPerMinute int
+}
+
+type tokenBucket struct {
+	capacity float64
+	level    float64
+	rate     float64 // per second
+}
+
+func newTokenBucket(perMinute int) tokenBucket {
+	return tokenBucket{
+		capacity: float64(perMinute),
+		level:    float64(perMinute),
+		rate:     float64(perMinute) / 60,
+	}
+}
+
+func (b *tokenBucket) enabled() bool {
+	return b.capacity > 0
+}
+
+func (b *tokenBucket) refill(elapsed time.Duration) {
+	b.level = min(b.capacity, b.level+elapsed.Seconds()*b.rate)
+}
+
+// waitFor returns the time needed to refill the bucket to the given level
+func (b *tokenBucket) waitFor(level float64) time.Duration {
+	if !b.enabled() || b.level >= level {
+		return 0
+	}
+	return time.Duration((level - b.level) / b.rate * float64(time.Second))
+}
+
+// rateLimiter is shared by all goroutines using one [Client]
+type rateLimiter struct {
+	mu sync.Mutex
+
+	requests tokenBucket
+	tokens   tokenBucket
+
+	lastRefill   time.Time
+	blockedUntil time.Time
+}
+
+func newRateLimiter(config RateLimiterConfig) *rateLimiter {
+	return &rateLimiter{
+		requests:   newTokenBucket(config.RequestsPerMinute),
+		tokens:     newTokenBucket(config.TokensPerMinute),
+		lastRefill: time.Now(),
+	}
+}
+
+// wait blocks until a request can be sent without exceeding rate limits or until ctx is done
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := rl.reserve(time.Now())
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes one request from the budget and returns zero, or returns the time to wait before trying again
+func (rl *rateLimiter) reserve(now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(now)
+
+	if now.Before(rl.blockedUntil) {
+		return rl.blockedUntil.Sub(now)
+	}
+
+	// Tokens are charged after the response is received, so only a positive balance is required here
+	delay := max(rl.requests.waitFor(1), rl.tokens.waitFor(1))
+	if delay > 0 {
+		return delay
+	}
+
+	if rl.requests.enabled() {
+		rl.requests.level--
+	}
+	return 0
+}
+
+func (rl *rateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(rl.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+
+	rl.requests.refill(elapsed)
+	rl.tokens.refill(elapsed)
+	rl.lastRefill = now
+}
+
+// observe updates the limiter with rate limit headers of a response
+func (rl *rateLimiter) observe(info RateLimitInfo) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if until, exhausted := info.exhaustedUntil(); exhausted && until.After(rl.blockedUntil) {
+		rl.blockedUntil = until
+	}
+
+	if info.Requests.Reported() && rl.requests.enabled() {
+		rl.requests.level = min(rl.requests.level, float64(info.Requests.Remaining))
+	}
+}
+
+// charge subtracts tokens used by a request from the token budget. The level can drop below zero,
+// which makes following requests wait until the budget is replenished.
+func (rl *rateLimiter) charge(usage Usage) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.tokens.enabled() {
+		rl.tokens.level -= float64(usage.InputTokens + usage.OutputTokens)
+	}
+}
@@ -0,0 +1,147 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitInfoFromResponse(t *testing.T) {
+	reset := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("anthropic-ratelimit-requests-limit", "50")
+			header.Set("anthropic-ratelimit-requests-remaining", "49")
+			header.Set("anthropic-ratelimit-requests-reset", reset.Format(time.RFC3339))
+			header.Set("anthropic-ratelimit-output-tokens-limit", "8000")
+			header.Set("anthropic-ratelimit-output-tokens-remaining", "7990")
+			header.Set("anthropic-ratelimit-output-tokens-reset", reset.Format(time.RFC3339))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"id":"msg_1","usage":{"input_tokens":5,"output_tokens":10}}`)),
+				Header:     header,
+			}
+		},
+	}
+
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient: MockHTTPClient(mockRoundTripper),
+	})
+
+	resp, err := client.CreateMessage(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, RateLimit{Limit: 50, Remaining: 49, Reset: reset}, resp.RateLimit.Requests)
+	assert.Equal(t, RateLimit{Limit: 8000, Remaining: 7990, Reset: reset}, resp.RateLimit.OutputTokens)
+	assert.False(t, resp.RateLimit.Tokens.Reported())
+}
+
+func TestRateLimiterRequestBudget(t *testing.T) {
+	rl := newRateLimiter(RateLimiterConfig{RequestsPerMinute: 60})
+	now := rl.lastRefill
+
+	for i := 0; i < 60; i++ {
+		assert.Zero(t, rl.reserve(now))
+	}
+
+	delay := rl.reserve(now)
+	assert.InDelta(t, time.Second, delay, float64(time.Millisecond))
+
+	assert.Zero(t, rl.reserve(now.Add(time.Second)))
+}
+
+func TestRateLimiterTokenBudget(t *testing.T) {
+	rl := newRateLimiter(RateLimiterConfig{TokensPerMinute: 600})
+	now := rl.lastRefill
+
+	assert.Zero(t, rl.reserve(now))
+	rl.charge(Usage{InputTokens: 500, OutputTokens: 200})
+
+	delay := rl.reserve(now)
+	assert.InDelta(t, 10100*time.Millisecond, delay, float64(time.Millisecond))
+}
+
+func TestRateLimiterObservesExhaustedLimits(t *testing.T) {
+	rl := newRateLimiter(RateLimiterConfig{})
+	now := rl.lastRefill
+
+	header := make(http.Header)
+	header.Set("anthropic-ratelimit-input-tokens-remaining", "0")
+	header.Set("anthropic-ratelimit-input-tokens-reset", now.Add(10*time.Second).UTC().Format(time.RFC3339))
+	rl.observe(newRateLimitInfo(header))
+
+	delay := rl.reserve(now)
+	assert.Greater(t, delay, 8*time.Second)
+	assert.LessOrEqual(t, delay, 10*time.Second)
+
+	assert.Zero(t, rl.reserve(now.Add(11*time.Second)))
+}
+
+func TestRateLimiterWaitRespectsContext(t *testing.T) {
+	rl := newRateLimiter(RateLimiterConfig{RequestsPerMinute: 1})
+	assert.NoError(t, rl.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, rl.wait(ctx), context.DeadlineExceeded)
+}
+
+const mockUsageStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"usage":{"input_tokens":100,"output_tokens":1}}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":14}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestRateLimiterChargedByStream(t *testing.T) {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("Content-Type", "text/event-stream")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(mockUsageStream)),
+				Header:     header,
+			}
+		},
+	}
+
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient:  MockHTTPClient(mockRoundTripper),
+		RateLimiter: &RateLimiterConfig{TokensPerMinute: 60},
+	})
+
+	stream, err := client.CreateMessageStream(context.Background(), MessageRequest{
+		Model:     Claude35SonnetModel,
+		MaxTokens: 1024,
+		Messages:  []InputMessage{{Role: MessageRoleUser, Content: "Hello"}},
+	})
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	// The budget is only charged once the usage of the whole message is known
+	assert.Zero(t, client.rateLimiter.reserve(time.Now()))
+
+	for {
+		if _, err = stream.RecvAll(); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, io.EOF)
+
+	// 60 - 114 tokens leaves the budget 54 tokens short, refilled at 1 token per second
+	delay := client.rateLimiter.reserve(time.Now())
+	assert.Greater(t, delay, 50*time.Second)
+	assert.LessOrEqual(t, delay, 55*time.Second)
+}
@@ -0,0 +1,225 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how the client retries failed requests. Zero value of RetryPolicy disables retries.
+//
+// Delay between attempts grows exponentially from BaseDelay and is capped at MaxDelay. If the response carries
+// a "retry-after" header or exhausted "anthropic-ratelimit-*-reset" headers, the delay they specify is used instead,
+// still capped at MaxDelay.
+type RetryPolicy struct {
+	// MaxAttempts is a total number of attempts, including the first one. Values lower than 2 disable retries
+	MaxAttempts int
+
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Jitter is a fraction (from 0 to 1) of the exponential delay that is randomly subtracted from it
+	Jitter float64
+
+	// RetryableStatusCodes are HTTP status codes of responses that are retried
+	RetryableStatusCodes []int
+	// RetryableErrorTypes are API error types (e.g. "overloaded_error") of responses that are retried regardless of status code
+	RetryableErrorTypes []string
+	// RetryNetworkErrors makes the client retry requests that failed without receiving a response
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy returns a retry policy used by [DefaultConfig]. It makes up to 3 attempts and retries
+// request timeouts, conflicts, rate limit errors, server errors and network errors.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    60 * time.Second,
+		Jitter:      0.25,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusConflict,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			StatusOverloaded,
+		},
+		RetryableErrorTypes: []string{
+			RateLimitErrorType,
+			APIErrorType,
+			OverloadedErrorType,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+const (
+	retryAfterHeader    = "retry-after"
+	retryAfterMsHeader  = "retry-after-ms"
+	shouldRetryHeader   = "x-should-retry"
+	rateLimitHeaderBase = "anthropic-ratelimit-"
+)
+
+// doRequest sends the request, retrying it according to the client's [RetryPolicy].
+// The last received response is returned as is, so the caller is responsible for checking its status code.
+func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
+	policy := c.config.RetryPolicy
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.rateLimiter != nil {
+			if err = c.rateLimiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.config.HTTPClient.Do(attemptReq)
+		if resp != nil && c.rateLimiter != nil {
+			c.rateLimiter.observe(newRateLimitInfo(resp.Header))
+		}
+
+		canRetry := attempt < policy.MaxAttempts && (req.Body == nil || req.GetBody != nil)
+		if !canRetry || !policy.shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			if serverDelay, ok := retryDelayFromHeaders(resp.Header, time.Now()); ok {
+				delay = serverDelay
+			}
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			resp.Body.Close()
+		}
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	attemptReq := req.Clone(req.Context())
+	attemptReq.Body = body
+	return attemptReq, nil
+}
+
+func (p RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return p.RetryNetworkErrors
+	}
+
+	if !isFailureStatusCode(resp) {
+		return false
+	}
+
+	switch resp.Header.Get(shouldRetryHeader) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	if len(p.RetryableErrorTypes) == 0 {
+		return false
+	}
+
+	// The body is read to find the error type and then restored, so the caller can still handle the error response
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return false
+	}
+
+	var errResp ErrorResponse
+	if json.Unmarshal(body, &errResp) != nil || errResp.Error == nil {
+		return false
+	}
+
+	for _, errType := range p.RetryableErrorTypes {
+		if errResp.Error.Type == errType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(float64(delay) * min(p.Jitter, 1) * rand.Float64())
+	}
+
+	return delay
+}
+
+// retryDelayFromHeaders returns the delay requested by the server with "retry-after" headers or,
+// if they are absent, the time left until the reset of exhausted rate limits
+func retryDelayFromHeaders(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get(retryAfterMsHeader), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	if retryAfter := header.Get(retryAfterHeader); retryAfter != "" {
+		if seconds, err := strconv.ParseFloat(retryAfter, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return max(date.Sub(now), 0), true
+		}
+	}
+
+	if until, exhausted := newRateLimitInfo(header).exhaustedUntil(); exhausted {
+		return max(until.Sub(now), 0), true
+	}
+
+	return 0, false
+}
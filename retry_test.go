@@ -0,0 +1,170 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 10 * time.Millisecond
+	return policy
+}
+
+func TestRetryOnOverloaded(t *testing.T) {
+	var (
+		attempts int
+		bodies   []string
+	)
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			attempts++
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+
+			if attempts < 3 {
+				return &http.Response{
+					StatusCode: StatusOverloaded,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)),
+					Header:     make(http.Header),
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"key":"value"}`)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient:  MockHTTPClient(mockRoundTripper),
+		RetryPolicy: testRetryPolicy(),
+	})
+
+	request, err := client.newRequest(context.Background(), http.MethodPost, "", withBody(map[string]string{"a": "b"}))
+	assert.NoError(t, err)
+
+	var result map[string]string
+	err = client.sendRequest(request, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", result["key"])
+	assert.Equal(t, 3, attempts)
+	for _, body := range bodies {
+		assert.Equal(t, `{"a":"b"}`, body)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			attempts++
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","error":{"type":"rate_limit_error","message":"Slow down"}}`)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient:  MockHTTPClient(mockRoundTripper),
+		RetryPolicy: testRetryPolicy(),
+	})
+
+	request, err := client.newRequest(context.Background(), http.MethodPost, "")
+	assert.NoError(t, err)
+
+	err = client.sendRequest(request, nil)
+	assert.True(t, IsRateLimit(err))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestNoRetryOnInvalidRequest(t *testing.T) {
+	attempts := 0
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			attempts++
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","error":{"type":"invalid_request_error","message":"Bad"}}`)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient:  MockHTTPClient(mockRoundTripper),
+		RetryPolicy: testRetryPolicy(),
+	})
+
+	request, err := client.newRequest(context.Background(), http.MethodPost, "")
+	assert.NoError(t, err)
+
+	err = client.sendRequest(request, nil)
+	assert.True(t, IsInvalidRequest(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryRespectsContext(t *testing.T) {
+	mockRoundTripper := &MockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			header := make(http.Header)
+			header.Set("retry-after", "30")
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       io.NopCloser(bytes.NewBufferString("")),
+				Header:     header,
+			}
+		},
+	}
+
+	policy := testRetryPolicy()
+	policy.MaxDelay = time.Minute
+	client := NewClientWithConfig(ClientConfig{
+		HTTPClient:  MockHTTPClient(mockRoundTripper),
+		RetryPolicy: policy,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	request, err := client.newRequest(ctx, http.MethodPost, "")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	err = client.sendRequest(request, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryDelayFromHeaders(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	header := make(http.Header)
+	header.Set("retry-after", "2")
+	delay, ok := retryDelayFromHeaders(header, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, delay)
+
+	header = make(http.Header)
+	header.Set("anthropic-ratelimit-requests-remaining", "10")
+	header.Set("anthropic-ratelimit-requests-reset", now.Add(time.Minute).Format(time.RFC3339))
+	header.Set("anthropic-ratelimit-tokens-remaining", "0")
+	header.Set("anthropic-ratelimit-tokens-reset", now.Add(5*time.Second).Format(time.RFC3339))
+	delay, ok = retryDelayFromHeaders(header, now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	_, ok = retryDelayFromHeaders(make(http.Header), now)
+	assert.False(t, ok)
+}
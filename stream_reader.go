@@ -1,17 +1,54 @@
 package anthropic
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+
+	utils "github.com/adamchol/go-anthropic-sdk/internal"
+)
+
+var (
+	ErrStreamTruncated     = errors.New("stream ended before the message_stop event")
+	ErrStreamEventMismatch = errors.New("stream event name does not match its type")
+	// ErrUnexpectedContentType is wrapped in [*RequestError] when a stream response is not of "text/event-stream" type
+	ErrUnexpectedContentType = errors.New("unexpected content type of stream response")
 )
 
 type streamReader struct {
-	reader   *bufio.Reader
+	ctx      context.Context
+	decoder  *utils.SSEDecoder
 	response *http.Response
+
+	// stopCloseOnDone unregisters closing of the response body when ctx is done
+	stopCloseOnDone func() bool
+
+	// finished is set after the last event of the stream, after which the stream ends with io.EOF
+	finished bool
+
+	accumulator *MessageAccumulator
+
+	// rateLimiter is charged with the usage of the message once the stream ends. It is nil if rate limiting is disabled
+	rateLimiter *rateLimiter
+	usage       Usage
+	charged     bool
+}
+
+func newStreamReader(ctx context.Context, resp *http.Response, limiter *rateLimiter) *streamReader {
+	return &streamReader{
+		ctx:      ctx,
+		decoder:  utils.NewSSEDecoder(resp.Body),
+		response: resp,
+		// Closing the body interrupts a pending read, even if the transport does not watch the context itself
+		stopCloseOnDone: context.AfterFunc(ctx, func() {
+			resp.Body.Close()
+		}),
+		accumulator: NewMessageAccumulator(),
+		rateLimiter: limiter,
+	}
 }
 
 // Recv is the same as RecvAll() but receives only events with the type "content_block_delta", which carry the content of the response,
@@ -27,7 +64,10 @@ func (stream *streamReader) Recv() (MessageStreamDelta, error) {
 		case ContentBlockDeltaStreamEventType:
 			return streamEvent.Delta, nil
 		case ErrStreamEventType:
-			return *new(MessageStreamDelta), fmt.Errorf("API error of type \"%s\": %s", streamEvent.Error.Type, streamEvent.Error.Message)
+			return *new(MessageStreamDelta), &APIError{
+				Type:    streamEvent.Error.Type,
+				Message: streamEvent.Error.Message,
+			}
 		case MessageStopStreamEventType:
 			return *new(MessageStreamDelta), io.EOF
 		default:
@@ -39,36 +79,83 @@ func (stream *streamReader) Recv() (MessageStreamDelta, error) {
 // RecvAll receives all types of events from Anthropic Messages API and returns them as [MessageStreamEvent]
 // If you want to process all events, check the type of event first to know what fields are available.
 func (stream *streamReader) RecvAll() (response MessageStreamEvent, err error) {
-	return stream.processLines()
+	response, err = stream.processEvent()
+	if err == nil && stream.accumulator != nil {
+		// Accumulation errors are reported by FinalMessage, events are still returned to the caller as they are
+		stream.accumulator.Add(response) //nolint:errcheck
+	}
+	return
 }
 
-func (stream *streamReader) processLines() (MessageStreamEvent, error) {
-	dataPrefix := []byte("data: ")
+func (stream *streamReader) processEvent() (MessageStreamEvent, error) {
+	if stream.finished {
+		return *new(MessageStreamEvent), io.EOF
+	}
 
-	for {
-		line, readErr := stream.reader.ReadBytes('\n')
-		if readErr != nil {
-			return *new(MessageStreamEvent), readErr
+	sseEvent, err := stream.decoder.Next()
+	if err != nil {
+		stream.chargeUsage()
+		if ctxErr := stream.ctx.Err(); ctxErr != nil {
+			return *new(MessageStreamEvent), ctxErr
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return *new(MessageStreamEvent), ErrStreamTruncated
 		}
+		return *new(MessageStreamEvent), err
+	}
 
-		if string(line) == "" {
-			continue
+	var event MessageStreamEvent
+	if err = json.Unmarshal(sseEvent.Data, &event); err != nil {
+		if sseEvent.Unterminated {
+			return *new(MessageStreamEvent), fmt.Errorf("%w: %w", ErrStreamTruncated, err)
 		}
+		return *new(MessageStreamEvent), err
+	}
 
-		if bytes.HasPrefix(line, dataPrefix) {
-			lineData := bytes.TrimPrefix(line, dataPrefix)
+	if sseEvent.Event != "" && sseEvent.Event != string(event.Type) {
+		return *new(MessageStreamEvent), fmt.Errorf("%w: \"%s\" and \"%s\"", ErrStreamEventMismatch, sseEvent.Event, event.Type)
+	}
 
-			var resp MessageStreamEvent
-			err := json.Unmarshal(lineData, &resp)
-			if err != nil {
-				return *new(MessageStreamEvent), err
-			}
+	// Both message_stop and error events terminate the stream
+	if event.Type == MessageStopStreamEventType || event.Type == ErrStreamEventType {
+		stream.finished = true
+	}
+
+	stream.trackUsage(event)
+	return event, nil
+}
 
-			return resp, nil
+// trackUsage updates the usage of the message with message_start and message_delta events,
+// and charges the rate limiter with it when the message stops
+func (stream *streamReader) trackUsage(event MessageStreamEvent) {
+	switch event.Type {
+	case MessageStartStreamEventType:
+		stream.usage = event.Message.Usage
+	case MessageDeltaStreamEventType:
+		if event.Usage != nil {
+			updateUsage(&stream.usage, *event.Usage)
 		}
+	case MessageStopStreamEventType:
+		stream.chargeUsage()
 	}
 }
 
+// chargeUsage charges the rate limiter with the usage received so far, once per stream
+func (stream *streamReader) chargeUsage() {
+	if stream.charged || stream.rateLimiter == nil {
+		return
+	}
+
+	stream.charged = true
+	stream.rateLimiter.charge(stream.usage)
+}
+
 func (stream *streamReader) Close() error {
+	// A stream closed before its end has still used tokens
+	stream.chargeUsage()
+
+	if stream.stopCloseOnDone != nil {
+		stream.stopCloseOnDone()
+	}
 	return stream.response.Body.Close()
 }
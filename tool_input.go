@@ -0,0 +1,84 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotToolUseBlock = errors.New("content block is not a tool_use block")
+
+type decodeInputOptions struct {
+	disallowUnknownFields bool
+}
+
+// DecodeInputOption configures [ContentBlock.DecodeInput] and [ToolInput]
+type DecodeInputOption func(*decodeInputOptions)
+
+// DisallowUnknownFields makes decoding fail if the input has a field that is not in the destination struct
+func DisallowUnknownFields() DecodeInputOption {
+	return func(o *decodeInputOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// DecodeInput decodes the input of a tool_use block into v, like [json.Unmarshal].
+// Numbers decoded into interface{} values are [json.Number], so they keep the exact value sent by the model.
+func (cb ContentBlock) DecodeInput(v any, opts ...DecodeInputOption) error {
+	if cb.Type != ToolUseContentObjectType {
+		return fmt.Errorf("%w: got \"%s\"", ErrNotToolUseBlock, cb.Type)
+	}
+
+	options := decodeInputOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	raw, err := cb.rawInput()
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if options.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err = decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid input of tool %s: %w", cb.Name, err)
+	}
+
+	if _, err = decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid input of tool %s: unexpected data after the input", cb.Name)
+	}
+	return nil
+}
+
+// ToolInput decodes the input of a tool_use block into a value of type T. See [ContentBlock.DecodeInput]
+func ToolInput[T any](block ContentBlock, opts ...DecodeInputOption) (T, error) {
+	var input T
+	err := block.DecodeInput(&input, opts...)
+	return input, err
+}
+
+// SetInput replaces the input of a tool_use block. It clears RawInput, so that the new input is sent
+// instead of the one received from the API
+func (cb *ContentBlock) SetInput(input map[string]interface{}) {
+	cb.Input = input
+	cb.RawInput = nil
+}
+
+// rawInput returns RawInput, or Input encoded to JSON for blocks that were not received from the API
+func (cb ContentBlock) rawInput() (json.RawMessage, error) {
+	if len(cb.RawInput) > 0 {
+		return cb.RawInput, nil
+	}
+
+	if cb.Input == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(cb.Input)
+}
@@ -0,0 +1,89 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type transferInput struct {
+	Account string `json:"account"`
+	Amount  int64  `json:"amount"`
+	Meta    any    `json:"meta"`
+}
+
+func TestToolInputFromResponse(t *testing.T) {
+	var message MessageResponse
+	err := json.Unmarshal([]byte(`{
+		"content": [{
+			"type": "tool_use",
+			"id": "toolu_1",
+			"name": "transfer",
+			"input": {"account": "acc_1", "amount": 9007199254740993, "meta": {"rate": 0.1}}
+		}]
+	}`), &message)
+	assert.NoError(t, err)
+
+	block := message.Content[0]
+	assert.JSONEq(t, `{"account": "acc_1", "amount": 9007199254740993, "meta": {"rate": 0.1}}`, string(block.RawInput))
+	assert.Equal(t, "acc_1", block.Input["account"])
+
+	input, err := ToolInput[transferInput](block)
+	assert.NoError(t, err)
+	assert.Equal(t, "acc_1", input.Account)
+	assert.Equal(t, int64(9007199254740993), input.Amount)
+	assert.Equal(t, map[string]interface{}{"rate": json.Number("0.1")}, input.Meta)
+
+	// The block is sent back with the input exactly as received
+	bs, err := json.Marshal(block)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"amount":9007199254740993`)
+}
+
+func TestDecodeInputUnknownFields(t *testing.T) {
+	block := ContentBlock{
+		Type:     ToolUseContentObjectType,
+		Name:     "transfer",
+		RawInput: json.RawMessage(`{"account": "acc_1", "currency": "EUR"}`),
+	}
+
+	var input transferInput
+	assert.NoError(t, block.DecodeInput(&input))
+	assert.Equal(t, "acc_1", input.Account)
+
+	_, err := ToolInput[transferInput](block, DisallowUnknownFields())
+	assert.ErrorContains(t, err, `unknown field "currency"`)
+}
+
+func TestDecodeInputWithoutRawInput(t *testing.T) {
+	block := ContentBlock{
+		Type:  ToolUseContentObjectType,
+		Name:  "transfer",
+		Input: map[string]interface{}{"account": "acc_1", "amount": 100},
+	}
+
+	input, err := ToolInput[transferInput](block)
+	assert.NoError(t, err)
+	assert.Equal(t, transferInput{Account: "acc_1", Amount: 100}, input)
+
+	_, err = ToolInput[transferInput](ContentBlock{Type: TextContentObjectType, Text: "Hello"})
+	assert.ErrorIs(t, err, ErrNotToolUseBlock)
+}
+
+func TestSetToolInput(t *testing.T) {
+	var block ContentBlock
+	err := json.Unmarshal([]byte(`{"type": "tool_use", "id": "toolu_1", "name": "transfer", "input": {"account": "acc_1", "amount": 100}}`), &block)
+	assert.NoError(t, err)
+
+	block.SetInput(map[string]interface{}{"account": "acc_1", "amount": 200})
+	assert.Nil(t, block.RawInput)
+
+	bs, err := json.Marshal(block)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "tool_use", "id": "toolu_1", "name": "transfer", "input": {"account": "acc_1", "amount": 200}}`, string(bs))
+
+	input, err := ToolInput[transferInput](block)
+	assert.NoError(t, err)
+	assert.Equal(t, transferInput{Account: "acc_1", Amount: 200}, input)
+}
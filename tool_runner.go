@@ -0,0 +1,240 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const defaultMaxToolIterations = 10
+
+var (
+	ErrToolAlreadyRegistered = errors.New("tool is already registered")
+	ErrMaxIterationsReached  = errors.New("maximum number of tool use iterations reached")
+)
+
+// ToolRunner drives a conversation with tools: it sends the request, calls the registered handlers for tool_use blocks
+// of the response, sends their results back and repeats until the model stops using tools.
+//
+// Register tools with [RegisterTool] before calling [ToolRunner.Run]. ToolRunner is safe for concurrent use after
+// all tools have been registered.
+type ToolRunner struct {
+	client *Client
+	tools  []Tool
+	// handlers of the tools by name
+	handlers map[string]toolHandler
+
+	// MaxIterations is the maximum number of requests sent by a single run. Defaults to 10
+	MaxIterations int
+	// StartToolsEarly makes [ToolRunner.RunStream] start every tool handler as soon as the input of its
+	// tool_use block has been received, instead of after the response ends with stop_reason "tool_use".
+	// Handlers may then run for responses that end otherwise, e.g. with "max_tokens" or a stream error,
+	// in which case their context is canceled and their results are discarded
+	StartToolsEarly bool
+}
+
+type toolHandler func(ctx context.Context, block ContentBlock) (string, error)
+
+// ToolRunResult is the result of [ToolRunner.Run]
+type ToolRunResult struct {
+	// Messages is the whole conversation: the messages of the request followed by the responses of the model
+	// and the tool results sent back. Pass it as Messages of the next request to continue the conversation
+	Messages []InputMessage
+	// Response is the last response of the model
+	Response MessageResponse
+	// Usage is the sum of usage of all requests of the run
+	Usage Usage
+	// Iterations is the number of requests sent
+	Iterations int
+}
+
+func NewToolRunner(client *Client) *ToolRunner {
+	return &ToolRunner{
+		client:   client,
+		handlers: make(map[string]toolHandler),
+	}
+}
+
+// RegisterTool adds a tool to the runner. The input schema of the tool is generated from T with [NewToolFromStruct],
+// and the input of every tool_use block is decoded into T before handler is called.
+//
+// The string returned by handler is sent as the tool result. If handler returns an error or panics, its message
+// is sent as the result with IsError set, so that the model can react to it.
+//
+// Handlers of all tool_use blocks of a response are called concurrently, without a limit on their number,
+// so handler must be safe for concurrent use.
+func RegisterTool[T any](r *ToolRunner, name, description string, handler func(ctx context.Context, input T) (string, error)) error {
+	if _, ok := r.handlers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrToolAlreadyRegistered, name)
+	}
+
+	tool, err := NewToolFromStruct[T](name, description)
+	if err != nil {
+		return err
+	}
+
+	r.tools = append(r.tools, tool)
+	r.handlers[name] = func(ctx context.Context, block ContentBlock) (string, error) {
+		input, err := ToolInput[T](block)
+		if err != nil {
+			return "", err
+		}
+		return handler(ctx, input)
+	}
+	return nil
+}
+
+// Run sends the request with registered tools added to its Tools, and keeps sending the tool results
+// until the model stops with a reason other than tool_use. A ToolChoice of type "any" or "tool" is only sent with
+// the first request, the following requests are sent with "auto".
+//
+// If the model still uses tools after MaxIterations requests, Run returns the result so far together with
+// [ErrMaxIterationsReached]. Errors of requests are returned with the result so far as well.
+func (r *ToolRunner) Run(ctx context.Context, request MessageRequest) (ToolRunResult, error) {
+	return r.run(ctx, request, func(ctx context.Context, request MessageRequest) (MessageResponse, []ContentBlock, error) {
+		response, err := r.client.CreateMessage(ctx, request)
+		if err != nil || response.StopReason != StopReasonToolUser {
+			return response, nil, err
+		}
+		return response, r.runTools(ctx, response.Content), nil
+	})
+}
+
+// toolRunStep sends a single request of a run and returns the response with results of the tools it used
+type toolRunStep func(ctx context.Context, request MessageRequest) (MessageResponse, []ContentBlock, error)
+
+func (r *ToolRunner) run(ctx context.Context, request MessageRequest, step toolRunStep) (result ToolRunResult, err error) {
+	request = r.prepareRequest(request)
+	result.Messages = request.Messages
+
+	for result.Iterations < r.maxIterations() {
+		request.Messages = result.Messages
+
+		var (
+			response    MessageResponse
+			toolResults []ContentBlock
+		)
+		response, toolResults, err = step(ctx, request)
+		if err != nil {
+			return
+		}
+
+		result.Iterations++
+		if done := result.addResponse(response); done || len(toolResults) == 0 {
+			return
+		}
+		result.addToolResults(toolResults)
+
+		// A forced tool use applies to the first request only, the model could not stop using tools otherwise
+		if request.ToolChoice != nil && (request.ToolChoice.Type == AnyToolChoiceType || request.ToolChoice.Type == ToolToolChoiceType) {
+			request.ToolChoice = &ToolChoice{Type: AutoToolChoiceType}
+		}
+	}
+
+	err = ErrMaxIterationsReached
+	return
+}
+
+// prepareRequest returns a copy of the request with registered tools added to its Tools
+func (r *ToolRunner) prepareRequest(request MessageRequest) MessageRequest {
+	tools := make([]Tool, 0, len(request.Tools)+len(r.tools))
+	tools = append(tools, request.Tools...)
+
+	for _, tool := range r.tools {
+		if !hasTool(request.Tools, tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+
+	request.Tools = tools
+	request.Messages = append([]InputMessage(nil), request.Messages...)
+	return request
+}
+
+func (r *ToolRunner) maxIterations() int {
+	if r.MaxIterations <= 0 {
+		return defaultMaxToolIterations
+	}
+	return r.MaxIterations
+}
+
+// runTools calls the handlers of all tool_use blocks concurrently and returns their tool_result blocks
+// in the order of the tool_use blocks
+func (r *ToolRunner) runTools(ctx context.Context, content []ContentBlock) []ContentBlock {
+	var toolUses []ContentBlock
+	for _, block := range content {
+		if block.Type == ToolUseContentObjectType {
+			toolUses = append(toolUses, block)
+		}
+	}
+
+	results := make([]ContentBlock, len(toolUses))
+
+	var wg sync.WaitGroup
+	for i, block := range toolUses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.runTool(ctx, block)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (r *ToolRunner) runTool(ctx context.Context, block ContentBlock) ContentBlock {
+	output, err := r.callHandler(ctx, block)
+	if err != nil {
+		result := ToolResultText(block.ID, err.Error())
+		result.IsError = true
+		return result
+	}
+	return ToolResultText(block.ID, output)
+}
+
+// callHandler calls the handler of the tool_use block. A panic of the handler is returned as an error,
+// as it runs in a goroutine of the runner, where it would crash the whole program
+func (r *ToolRunner) callHandler(ctx context.Context, block ContentBlock) (output string, err error) {
+	handler, ok := r.handlers[block.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %s", block.Name)
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("tool %s panicked: %v", block.Name, v)
+		}
+	}()
+
+	return handler(ctx, block)
+}
+
+// addResponse adds the response to the result and reports whether the run is done
+func (result *ToolRunResult) addResponse(response MessageResponse) bool {
+	result.Response = response
+	result.Usage.add(response.Usage)
+	result.Messages = append(result.Messages, InputMessage{
+		Role:          MessageRoleAssistant,
+		ContentBlocks: response.Content,
+	})
+
+	return response.StopReason != StopReasonToolUser
+}
+
+func (result *ToolRunResult) addToolResults(toolResults []ContentBlock) {
+	result.Messages = append(result.Messages, InputMessage{
+		Role:          MessageRoleUser,
+		ContentBlocks: toolResults,
+	})
+}
+
+func hasTool(tools []Tool, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package anthropic
+
+import "context"
+
+// ToolRunHandlers are callbacks called by [ToolRunner.RunStream] as the run progresses. All of them are optional.
+// Callbacks are called sequentially from the goroutine that called RunStream.
+type ToolRunHandlers struct {
+	// OnText is called for every "text_delta" event of every response
+	OnText func(text string)
+	// OnToolUseStart is called when the model starts a tool_use block. Its input is not known yet
+	OnToolUseStart func(block ContentBlock)
+	// OnToolUseComplete is called with the complete tool_use block, as soon as its input has been received
+	OnToolUseComplete func(block ContentBlock)
+	// OnToolResult is called when a tool handler returns, with the tool_result block that is sent back
+	OnToolResult func(result ContentBlock)
+	// OnMessage is called with every complete response of the model
+	OnMessage func(message MessageResponse)
+}
+
+// RunStream is like [ToolRunner.Run], but streams the responses with [Client.CreateMessageStream] and reports
+// the progress to handlers. Tool handlers are started once the response has ended with stop_reason "tool_use",
+// unless [ToolRunner.StartToolsEarly] is set.
+func (r *ToolRunner) RunStream(ctx context.Context, request MessageRequest, handlers ToolRunHandlers) (ToolRunResult, error) {
+	return r.run(ctx, request, func(ctx context.Context, request MessageRequest) (MessageResponse, []ContentBlock, error) {
+		return r.streamStep(ctx, request, handlers)
+	})
+}
+
+type indexedToolResult struct {
+	index  int
+	result ContentBlock
+}
+
+func (r *ToolRunner) streamStep(ctx context.Context, request MessageRequest, handlers ToolRunHandlers) (MessageResponse, []ContentBlock, error) {
+	toolCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		started int
+		results = make(chan indexedToolResult)
+	)
+
+	message, err := r.client.StreamMessage(ctx, request, StreamHandlers{
+		OnText: func(_ int, text string) {
+			if handlers.OnText != nil {
+				handlers.OnText(text)
+			}
+		},
+		OnContentBlockStart: func(_ int, block ContentBlock) {
+			if block.Type == ToolUseContentObjectType && handlers.OnToolUseStart != nil {
+				handlers.OnToolUseStart(block)
+			}
+		},
+		OnContentBlockStop: func(_ int, block ContentBlock) {
+			if block.Type != ToolUseContentObjectType {
+				return
+			}
+
+			if handlers.OnToolUseComplete != nil {
+				handlers.OnToolUseComplete(block)
+			}
+			if !r.StartToolsEarly {
+				return
+			}
+
+			index := started
+			started++
+			go func() {
+				results <- indexedToolResult{index: index, result: r.runTool(toolCtx, block)}
+			}()
+		},
+	})
+
+	if err == nil && handlers.OnMessage != nil {
+		handlers.OnMessage(message)
+	}
+
+	if err != nil || message.StopReason != StopReasonToolUser {
+		// Tools that have been started are not needed anymore
+		cancel()
+		for range started {
+			<-results
+		}
+		return message, nil, err
+	}
+
+	if !r.StartToolsEarly {
+		toolResults := r.runTools(ctx, message.Content)
+		if handlers.OnToolResult != nil {
+			for _, result := range toolResults {
+				handlers.OnToolResult(result)
+			}
+		}
+		return message, toolResults, nil
+	}
+
+	toolResults := make([]ContentBlock, started)
+	for range started {
+		result := <-results
+		toolResults[result.index] = result.result
+
+		if handlers.OnToolResult != nil {
+			handlers.OnToolResult(result.result)
+		}
+	}
+
+	return message, toolResults, nil
+}
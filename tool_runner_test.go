@@ -0,0 +1,339 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type weatherToolInput struct {
+	City string `json:"city"`
+}
+
+// newToolUseServer responds with the given responses in order and records the requests
+func newToolUseServer(t *testing.T, responses ...string) (*httptest.Server, *[]MessageRequest) {
+	t.Helper()
+
+	var (
+		requests []MessageRequest
+		mu       sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var request MessageRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+
+		response := responses[min(len(requests), len(responses))-1]
+		if strings.HasPrefix(response, "event:") {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newToolRunnerClient(url string) *Client {
+	config := DefaultConfig("mock-key")
+	config.BaseUrl = url
+	return NewClientWithConfig(config)
+}
+
+const toolUseResponse = `{
+	"id": "msg_1",
+	"type": "message",
+	"role": "assistant",
+	"content": [
+		{"type": "text", "text": "Let me check both cities."},
+		{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}},
+		{"type": "tool_use", "id": "toolu_2", "name": "get_weather", "input": {"city": "Atlantis"}}
+	],
+	"stop_reason": "tool_use",
+	"usage": {"input_tokens": 10, "output_tokens": 20}
+}`
+
+const endTurnResponse = `{
+	"id": "msg_2",
+	"type": "message",
+	"role": "assistant",
+	"content": [{"type": "text", "text": "It's sunny in Paris."}],
+	"stop_reason": "end_turn",
+	"usage": {"input_tokens": 30, "output_tokens": 5}
+}`
+
+func TestToolRunner(t *testing.T) {
+	server, requests := newToolUseServer(t, toolUseResponse, endTurnResponse)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+
+	// Both calls have to be running at the same time to return
+	var started sync.WaitGroup
+	started.Add(2)
+
+	err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input weatherToolInput) (string, error) {
+		started.Done()
+		started.Wait()
+
+		if input.City == "Atlantis" {
+			return "", errors.New("city not found")
+		}
+		return "Sunny", nil
+	})
+	assert.NoError(t, err)
+
+	err = RegisterTool(runner, "get_weather", "", func(ctx context.Context, input weatherToolInput) (string, error) {
+		return "", nil
+	})
+	assert.ErrorIs(t, err, ErrToolAlreadyRegistered)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := runner.Run(ctx, mockMessageRequest("mock"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, result.Iterations)
+	assert.Equal(t, "msg_2", result.Response.ID)
+	assert.Equal(t, Usage{InputTokens: 40, OutputTokens: 25}, result.Usage)
+
+	assert.Len(t, result.Messages, 4)
+	assert.Equal(t, MessageRoleAssistant, result.Messages[1].Role)
+	assert.Equal(t, []ContentBlock{
+		{
+			Type:           ToolResultContentObjectType,
+			ToolUseId:      "toolu_1",
+			ToolResultText: "Sunny",
+		},
+		{
+			Type:           ToolResultContentObjectType,
+			ToolUseId:      "toolu_2",
+			IsError:        true,
+			ToolResultText: "city not found",
+		},
+	}, result.Messages[2].ContentBlocks)
+
+	assert.Len(t, *requests, 2)
+	assert.Equal(t, "get_weather", (*requests)[0].Tools[0].Name)
+	assert.Equal(t, "object", (*requests)[0].Tools[0].InputSchema["type"])
+	assert.Len(t, (*requests)[1].Messages, 3)
+}
+
+func TestToolRunnerMaxIterations(t *testing.T) {
+	server, requests := newToolUseServer(t, toolUseResponse)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+	runner.MaxIterations = 2
+
+	// get_weather is not registered, so the results are errors
+	result, err := runner.Run(context.Background(), mockMessageRequest("mock"))
+	assert.ErrorIs(t, err, ErrMaxIterationsReached)
+
+	assert.Equal(t, 2, result.Iterations)
+	assert.Len(t, *requests, 2)
+	assert.Len(t, result.Messages, 5)
+	assert.True(t, result.Messages[4].ContentBlocks[0].IsError)
+	assert.Equal(t, "unknown tool get_weather", result.Messages[4].ContentBlocks[0].ToolResultText)
+}
+
+func TestToolRunnerForcedToolChoice(t *testing.T) {
+	server, requests := newToolUseServer(t, toolUseResponse, endTurnResponse)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+	err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input weatherToolInput) (string, error) {
+		return "Sunny", nil
+	})
+	assert.NoError(t, err)
+
+	request := mockMessageRequest("mock")
+	request.ToolChoice = &ToolChoice{Type: ToolToolChoiceType, Name: "get_weather"}
+
+	result, err := runner.Run(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Iterations)
+
+	assert.Len(t, *requests, 2)
+	assert.Equal(t, &ToolChoice{Type: ToolToolChoiceType, Name: "get_weather"}, (*requests)[0].ToolChoice)
+	assert.Equal(t, &ToolChoice{Type: AutoToolChoiceType}, (*requests)[1].ToolChoice)
+	assert.Equal(t, &ToolChoice{Type: ToolToolChoiceType, Name: "get_weather"}, request.ToolChoice)
+}
+
+func TestToolRunnerPanickingTool(t *testing.T) {
+	server, _ := newToolUseServer(t, toolUseResponse, endTurnResponse)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+	err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input weatherToolInput) (string, error) {
+		if input.City == "Atlantis" {
+			panic("city not found")
+		}
+		return "Sunny", nil
+	})
+	assert.NoError(t, err)
+
+	result, err := runner.Run(context.Background(), mockMessageRequest("mock"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []ContentBlock{
+		{
+			Type:           ToolResultContentObjectType,
+			ToolUseId:      "toolu_1",
+			ToolResultText: "Sunny",
+		},
+		{
+			Type:           ToolResultContentObjectType,
+			ToolUseId:      "toolu_2",
+			IsError:        true,
+			ToolResultText: "tool get_weather panicked: city not found",
+		},
+	}, result.Messages[2].ContentBlocks)
+}
+
+const endTurnStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_2","type":"message","role":"assistant","content":[],"model":"claude-3-5-sonnet-20240620","usage":{"input_tokens":40,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"It's foggy."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":4}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestToolRunnerStream(t *testing.T) {
+	server, requests := newToolUseServer(t, mockToolUseStream, endTurnStream)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+	err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input struct {
+		Location string `json:"location"`
+	}) (string, error) {
+		return "Foggy in " + input.Location, nil
+	})
+	assert.NoError(t, err)
+
+	var events []string
+	result, err := runner.RunStream(context.Background(), mockMessageRequest("mock"), ToolRunHandlers{
+		OnText: func(text string) {
+			events = append(events, "text: "+text)
+		},
+		OnToolUseStart: func(block ContentBlock) {
+			events = append(events, "start: "+block.Name)
+		},
+		OnToolUseComplete: func(block ContentBlock) {
+			events = append(events, "complete: "+string(block.RawInput))
+		},
+		OnToolResult: func(result ContentBlock) {
+			events = append(events, "result: "+result.ToolResultText)
+		},
+		OnMessage: func(message MessageResponse) {
+			events = append(events, "message: "+message.ID)
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"text: Let me check",
+		"text:  the weather.",
+		"start: get_weather",
+		`complete: {"location": "San Francisco, CA"}`,
+		"message: msg_1",
+		"result: Foggy in San Francisco, CA",
+		"text: It's foggy.",
+		"message: msg_2",
+	}, events)
+
+	assert.Equal(t, 2, result.Iterations)
+	assert.Equal(t, Usage{InputTokens: 65, OutputTokens: 93}, result.Usage)
+	assert.Len(t, result.Messages, 4)
+	assert.Equal(t, "It's foggy.", result.Response.Content[0].Text)
+
+	assert.Len(t, *requests, 2)
+	assert.True(t, (*requests)[1].Stream)
+	assert.Equal(t, "toolu_1", (*requests)[1].Messages[2].ContentBlocks[0].ToolUseId)
+}
+
+func TestToolRunnerStreamStopReason(t *testing.T) {
+	maxTokensStream := strings.Replace(mockToolUseStream, `"stop_reason":"tool_use"`, `"stop_reason":"max_tokens"`, 1)
+
+	for _, early := range []bool{false, true} {
+		t.Run(fmt.Sprintf("StartToolsEarly=%v", early), func(t *testing.T) {
+			server, requests := newToolUseServer(t, maxTokensStream)
+
+			runner := NewToolRunner(newToolRunnerClient(server.URL))
+			runner.StartToolsEarly = early
+
+			var called atomic.Bool
+			err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input struct {
+				Location string `json:"location"`
+			}) (string, error) {
+				called.Store(true)
+				<-ctx.Done()
+				return "", ctx.Err()
+			})
+			assert.NoError(t, err)
+
+			result, err := runner.RunStream(context.Background(), mockMessageRequest("mock"), ToolRunHandlers{
+				OnToolResult: func(result ContentBlock) {
+					t.Errorf("unexpected tool result %+v", result)
+				},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, StopReasonMaxTokens, result.Response.StopReason)
+			assert.Equal(t, 1, result.Iterations)
+			assert.Len(t, *requests, 1)
+
+			// only a tool started early runs before the stop_reason is known
+			assert.Equal(t, early, called.Load())
+		})
+	}
+}
+
+func TestToolRunnerStreamStartToolsEarly(t *testing.T) {
+	server, requests := newToolUseServer(t, mockToolUseStream, endTurnStream)
+
+	runner := NewToolRunner(newToolRunnerClient(server.URL))
+	runner.StartToolsEarly = true
+	err := RegisterTool(runner, "get_weather", "Get the weather", func(ctx context.Context, input struct {
+		Location string `json:"location"`
+	}) (string, error) {
+		return "Foggy in " + input.Location, nil
+	})
+	assert.NoError(t, err)
+
+	var results []string
+	result, err := runner.RunStream(context.Background(), mockMessageRequest("mock"), ToolRunHandlers{
+		OnToolResult: func(result ContentBlock) {
+			results = append(results, result.ToolResultText)
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Foggy in San Francisco, CA"}, results)
+	assert.Equal(t, 2, result.Iterations)
+	assert.Len(t, *requests, 2)
+	assert.Equal(t, "Foggy in San Francisco, CA", (*requests)[1].Messages[2].ContentBlocks[0].ToolResultText)
+}
@@ -0,0 +1,396 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedSchemaType = errors.New("type can't be described by a JSON schema")
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	jsonNumberType = reflect.TypeFor[json.Number]()
+)
+
+// NewToolFromStruct creates a tool with the InputSchema generated from the fields of struct T, so that the schema
+// always matches the type the tool input is decoded into.
+//
+// Property names and optionality follow encoding/json: fields are named by their json tag, and fields with
+// omitempty or of pointer type are optional, all others are required. Additional properties of a field
+// are set with the jsonschema tag, a comma separated list of:
+//
+//	description=...   description of the property, commas inside have to be escaped as \,
+//	enum=...          allowed value, repeat for each value
+//	required          makes the field required even if it's a pointer or has omitempty
+//	optional          makes the field optional
+//
+// For example:
+//
+//	type WeatherInput struct {
+//		City string  `json:"city" jsonschema:"description=Name of the city"`
+//		Unit *string `json:"unit" jsonschema:"enum=celsius,enum=fahrenheit"`
+//	}
+//
+// Recursive types, channels, functions and maps with non-string keys are not supported.
+func NewToolFromStruct[T any](name, description string) (Tool, error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return Tool{}, fmt.Errorf("%w: tool input must be a struct, got %s", ErrUnsupportedSchemaType, t)
+	}
+
+	g := &schemaGenerator{visiting: make(map[reflect.Type]bool)}
+	schema, err := g.schema(t)
+	if err != nil {
+		return Tool{}, err
+	}
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+	}, nil
+}
+
+type schemaGenerator struct {
+	// visiting holds struct types that are being generated, to detect recursive types
+	visiting map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) (map[string]interface{}, error) {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]interface{}{}, nil
+	case jsonNumberType:
+		return map[string]interface{}{"type": "number"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		// encoding/json encodes []byte as a base64 string
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		schema := map[string]interface{}{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			schema["minItems"] = t.Len()
+			schema["maxItems"] = t.Len()
+		}
+		return schema, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: map key of %s must be a string", ErrUnsupportedSchemaType, t)
+		}
+
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSchemaType, t)
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (map[string]interface{}, error) {
+	if g.visiting[t] {
+		return nil, fmt.Errorf("%w: %s is recursive", ErrUnsupportedSchemaType, t)
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	var fields []schemaField
+	if err := g.collectFields(t, 0, &fields); err != nil {
+		return nil, err
+	}
+
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, field := range dominantFields(fields) {
+		property, isRequired, err := g.fieldSchema(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		properties[field.name] = property
+		if isRequired {
+			required = append(required, field.name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       ObjectToolInputSchemaType,
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// schemaField is a field of a struct, or of a struct embedded in it, that becomes a property of its schema
+type schemaField struct {
+	reflect.StructField
+	name        string
+	jsonOptions string
+	// depth is the number of embedded structs the field is nested in
+	depth int
+	// tagged is true if the name comes from a json tag
+	tagged bool
+}
+
+// collectFields collects fields of struct t. Fields of embedded structs without a json name
+// are collected as if they were fields of t, the same as encoding/json does
+func (g *schemaGenerator) collectFields(t reflect.Type, depth int, fields *[]schemaField) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, jsonOptions, _ := strings.Cut(jsonTag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if g.visiting[embedded] {
+					return fmt.Errorf("%w: %s is recursive", ErrUnsupportedSchemaType, embedded)
+				}
+				g.visiting[embedded] = true
+				err := g.collectFields(embedded, depth+1, fields)
+				delete(g.visiting, embedded)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+
+		*fields = append(*fields, schemaField{
+			StructField: field,
+			name:        name,
+			jsonOptions: jsonOptions,
+			depth:       depth,
+			tagged:      tagged,
+		})
+	}
+	return nil
+}
+
+// dominantFields resolves fields with the same name by the rules of encoding/json: the least nested field wins,
+// then the one with a json tag. Fields that are still ambiguous are left out, encoding/json ignores them too
+func dominantFields(fields []schemaField) []schemaField {
+	byName := make(map[string][]schemaField)
+	for _, field := range fields {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	var dominant []schemaField
+	for _, field := range fields {
+		candidates := byName[field.name]
+		if candidates == nil {
+			continue
+		}
+		// handle every name once, at the position of its first field
+		byName[field.name] = nil
+
+		if winner, ok := dominantField(candidates); ok {
+			dominant = append(dominant, winner)
+		}
+	}
+	return dominant
+}
+
+func dominantField(candidates []schemaField) (schemaField, bool) {
+	depth := candidates[0].depth
+	for _, candidate := range candidates {
+		depth = min(depth, candidate.depth)
+	}
+
+	var shallowest, tagged []schemaField
+	for _, candidate := range candidates {
+		if candidate.depth != depth {
+			continue
+		}
+		shallowest = append(shallowest, candidate)
+		if candidate.tagged {
+			tagged = append(tagged, candidate)
+		}
+	}
+
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return schemaField{}, false
+	}
+}
+
+// fieldSchema returns the property of a field and whether it's required
+func (g *schemaGenerator) fieldSchema(field schemaField) (map[string]interface{}, bool, error) {
+	property, err := g.schema(field.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	isRequired := field.Type.Kind() != reflect.Pointer && !hasTagOption(field.jsonOptions, "omitempty")
+
+	tag, err := parseSchemaTag(field.Tag.Get("jsonschema"))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if tag.description != "" {
+		property["description"] = tag.description
+	}
+	if len(tag.enum) > 0 {
+		if err = addEnum(property, tag.enum); err != nil {
+			return nil, false, err
+		}
+	}
+	if tag.required {
+		isRequired = true
+	}
+	if tag.optional {
+		isRequired = false
+	}
+	return property, isRequired, nil
+}
+
+type schemaTag struct {
+	description string
+	enum        []string
+	required    bool
+	optional    bool
+}
+
+func parseSchemaTag(tag string) (parsed schemaTag, err error) {
+	if tag == "" {
+		return
+	}
+
+	for _, option := range splitEscaped(tag, ',') {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "description":
+			parsed.description = value
+		case "enum":
+			parsed.enum = append(parsed.enum, value)
+		case "required":
+			parsed.required = true
+		case "optional":
+			parsed.optional = true
+		default:
+			err = fmt.Errorf("unknown jsonschema tag option %q", key)
+			return
+		}
+	}
+	return
+}
+
+// splitEscaped splits s by sep, except for separators escaped with a backslash
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	var current strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == sep:
+			current.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	return append(parts, current.String())
+}
+
+// addEnum sets enum values converted to the type of the property. Enum of an array property applies to its items
+func addEnum(property map[string]interface{}, values []string) error {
+	if property["type"] == "array" {
+		property = property["items"].(map[string]interface{})
+	}
+
+	enum := make([]interface{}, len(values))
+	for i, value := range values {
+		var err error
+		switch property["type"] {
+		case "string":
+			enum[i] = value
+		case "integer":
+			enum[i], err = strconv.ParseInt(value, 10, 64)
+		case "number":
+			enum[i], err = strconv.ParseFloat(value, 64)
+		case "boolean":
+			enum[i], err = strconv.ParseBool(value)
+		default:
+			return fmt.Errorf("enum is not supported for type %v", property["type"])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid enum value %q: %w", value, err)
+		}
+	}
+
+	property["enum"] = enum
+	return nil
+}
+
+func hasTagOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
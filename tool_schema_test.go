@@ -0,0 +1,141 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type weatherLocation struct {
+	City    string `json:"city" jsonschema:"description=Name of the city\\, e.g. Paris"`
+	Country string `json:"country,omitempty"`
+}
+
+type weatherInput struct {
+	Location weatherLocation   `json:"location"`
+	Unit     *string           `json:"unit" jsonschema:"enum=celsius,enum=fahrenheit"`
+	Days     int               `json:"days" jsonschema:"enum=1,enum=3,enum=7,optional"`
+	Details  []string          `json:"details,omitempty" jsonschema:"enum=wind,enum=rain"`
+	Since    *time.Time        `json:"since" jsonschema:"required"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestNewToolFromStruct(t *testing.T) {
+	tool, err := NewToolFromStruct[weatherInput]("get_weather", "Get the weather forecast")
+	assert.NoError(t, err)
+	assert.Equal(t, "get_weather", tool.Name)
+	assert.Equal(t, "Get the weather forecast", tool.Description)
+
+	bs, err := json.Marshal(tool.InputSchema)
+	assert.NoError(t, err)
+
+	expectedJSON := `{
+		"type": "object",
+		"properties": {
+			"location": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string", "description": "Name of the city, e.g. Paris"},
+					"country": {"type": "string"}
+				},
+				"required": ["city"]
+			},
+			"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+			"days": {"type": "integer", "enum": [1, 3, 7]},
+			"details": {"type": "array", "items": {"type": "string", "enum": ["wind", "rain"]}},
+			"since": {"type": "string", "format": "date-time"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["location", "since"]
+	}`
+	assert.JSONEq(t, expectedJSON, string(bs))
+}
+
+type embeddedPaging struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+type searchInput struct {
+	embeddedPaging
+	Query string `json:"query"`
+}
+
+func TestNewToolFromStructEmbedded(t *testing.T) {
+	tool, err := NewToolFromStruct[*searchInput]("search", "")
+	assert.NoError(t, err)
+
+	bs, err := json.Marshal(tool.InputSchema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {"limit": {"type": "integer"}, "query": {"type": "string"}},
+		"required": ["query"]
+	}`, string(bs))
+}
+
+type pageCursor struct {
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor"`
+	Sort   string
+}
+
+type pageOrder struct {
+	Limit  *int   `json:"limit"`
+	Cursor string `json:"cursor"`
+	Order  int    `json:"Sort"`
+}
+
+type conflictingInput struct {
+	pageCursor
+	*pageOrder
+	Limit string `json:"limit,omitempty"`
+}
+
+func TestNewToolFromStructEmbeddedConflicts(t *testing.T) {
+	tool, err := NewToolFromStruct[conflictingInput]("search", "")
+	assert.NoError(t, err)
+
+	bs, err := json.Marshal(tool.InputSchema)
+	assert.NoError(t, err)
+	// the shallowest field wins, then the tagged one, and cursor is ambiguous, like in encoding/json
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"limit": {"type": "string"},
+			"offset": {"type": "integer"},
+			"Sort": {"type": "integer"}
+		},
+		"required": ["offset", "Sort"]
+	}`, string(bs))
+
+	bs, err = json.Marshal(conflictingInput{pageOrder: &pageOrder{}, Limit: "10"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"limit": "10", "offset": 0, "Sort": 0}`, string(bs))
+}
+
+type recursiveInput struct {
+	Children []recursiveInput `json:"children"`
+}
+
+func TestNewToolFromStructUnsupported(t *testing.T) {
+	_, err := NewToolFromStruct[string]("tool", "")
+	assert.ErrorIs(t, err, ErrUnsupportedSchemaType)
+
+	_, err = NewToolFromStruct[recursiveInput]("tool", "")
+	assert.ErrorIs(t, err, ErrUnsupportedSchemaType)
+
+	_, err = NewToolFromStruct[struct {
+		Callback func() `json:"callback"`
+	}]("tool", "")
+	assert.ErrorIs(t, err, ErrUnsupportedSchemaType)
+
+	_, err = NewToolFromStruct[struct {
+		Count int `json:"count" jsonschema:"enum=many"`
+	}]("tool", "")
+	assert.Error(t, err)
+}